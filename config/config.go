@@ -3,14 +3,19 @@ package config
 import (
 	"github.com/ilyakaznacheev/cleanenv"
 	"log"
+	"time"
 )
 
 type Config struct {
-	Postgres PostgresConfig `yaml:"postgres"`
-	Redis    RedisConfig    `yaml:"redis"`
-	RabbitMQ RabbitMQ       `yaml:"rabbitmq"`
-	App      App            `yaml:"app"`
-	Metrics  Metrics        `yaml:"metrics"`
+	Postgres      PostgresConfig `yaml:"postgres"`
+	Redis         RedisConfig    `yaml:"redis"`
+	RabbitMQ      RabbitMQ       `yaml:"rabbitmq"`
+	App           App            `yaml:"app"`
+	Metrics       Metrics        `yaml:"metrics"`
+	Notifications Notifications  `yaml:"notifications"`
+	Digest        Digest         `yaml:"digest"`
+	Outbox        Outbox         `yaml:"outbox"`
+	Gateway       Gateway        `yaml:"gateway"`
 }
 
 type PostgresConfig struct {
@@ -38,6 +43,18 @@ type RabbitMQ struct {
 	QueueName    string `yaml:"queueName" env-required:"true"`
 	ConsumerTag  string `yaml:"consumerTag" env-required:"true"`
 	BindingKey   string `yaml:"bindingKey" env-required:"true"`
+
+	Workers  int `yaml:"workers" env-default:"5"`
+	Prefetch int `yaml:"prefetch" env-default:"10"`
+
+	ReconnectBaseDelay time.Duration `yaml:"reconnectBaseDelay" env-default:"500ms"`
+	ReconnectMaxDelay  time.Duration `yaml:"reconnectMaxDelay" env-default:"30s"`
+
+	MaxRetries         int           `yaml:"maxRetries" env-default:"5"`
+	RetryBaseDelay     time.Duration `yaml:"retryBaseDelay" env-default:"1s"`
+	RetryMaxDelay      time.Duration `yaml:"retryMaxDelay" env-default:"5m"`
+	DeadLetterExchange string        `yaml:"deadLetterExchange" env-default:"notification-dlx"`
+	DeadLetterQueue    string        `yaml:"deadLetterQueue" env-default:"notification-dlq"`
 }
 
 type Metrics struct {
@@ -48,8 +65,79 @@ type Jaeger struct {
 	Endpoint string `yaml:"endpoint"`
 }
 
+type Notifications struct {
+	Aggregation Aggregation `yaml:"aggregation"`
+	QuietHours  QuietHours  `yaml:"quietHours"`
+	Dedup       Dedup       `yaml:"dedup"`
+	FanOut      FanOut      `yaml:"fanOut"`
+	Counts      Counts      `yaml:"counts"`
+}
+
+type Aggregation struct {
+	Enabled bool          `yaml:"enabled"`
+	Window  time.Duration `yaml:"window" env-default:"1h"`
+}
+
+type QuietHours struct {
+	ReleaseInterval time.Duration `yaml:"releaseInterval" env-default:"30s"`
+}
+
+type FanOut struct {
+	ChunkSize        int           `yaml:"chunkSize" env-default:"1000"`
+	Concurrency      int           `yaml:"concurrency" env-default:"4"`
+	HybridThreshold  int64         `yaml:"hybridThreshold"`
+	FeedCursorTTL    time.Duration `yaml:"feedCursorTTL" env-default:"10s"`
+	FeedUnreadWindow time.Duration `yaml:"feedUnreadWindow" env-default:"168h"`
+}
+
+type Counts struct {
+	ReconcileInterval  time.Duration `yaml:"reconcileInterval" env-default:"1h"`
+	ReconcileBatchSize int           `yaml:"reconcileBatchSize" env-default:"1000"`
+}
+
+type Dedup struct {
+	TTL time.Duration `yaml:"ttl" env-default:"24h"`
+}
+
+type Digest struct {
+	Enabled   bool          `yaml:"enabled"`
+	Interval  time.Duration `yaml:"interval" env-default:"5m"`
+	Template  string        `yaml:"template"`
+	Sender    string        `yaml:"sender" env-default:"log"`
+	OutputDir string        `yaml:"outputDir"`
+	SMTP      SMTP          `yaml:"smtp"`
+}
+
+type Outbox struct {
+	Enabled   bool          `yaml:"enabled"`
+	Exchange  string        `yaml:"exchange" env-default:"notification-events"`
+	Interval  time.Duration `yaml:"interval" env-default:"1s"`
+	BatchSize int           `yaml:"batchSize" env-default:"100"`
+	Lease     time.Duration `yaml:"lease" env-default:"30s"`
+}
+
+type Gateway struct {
+	Enabled        bool     `yaml:"enabled" env:"GATEWAY_ENABLED"`
+	TokenSecret    string   `yaml:"tokenSecret" env:"GATEWAY_TOKEN_SECRET"`
+	AllowedOrigins []string `yaml:"allowedOrigins" env:"GATEWAY_ALLOWED_ORIGINS" env-separator:","`
+}
+
+type SMTP struct {
+	Host     string        `yaml:"host" env:"SMTP_HOST"`
+	Port     string        `yaml:"port" env:"SMTP_PORT"`
+	Username string        `yaml:"username" env:"SMTP_USERNAME"`
+	Password string        `yaml:"password" env:"SMTP_PASSWORD"`
+	From     string        `yaml:"from" env:"SMTP_FROM"`
+	Timeout  time.Duration `yaml:"timeout" env-default:"30s"`
+}
+
 type App struct {
-	Port string `yaml:"port"`
+	Port                string        `yaml:"port"`
+	HTTPPort            string        `yaml:"httpPort"`
+	HealthCheckInterval time.Duration `yaml:"healthCheckInterval" env-default:"10s"`
+	ShutdownTimeout     time.Duration `yaml:"shutdownTimeout" env-default:"30s"`
+	TraceFlushTimeout   time.Duration `yaml:"traceFlushTimeout" env-default:"5s"`
+	AdminToken          string        `yaml:"adminToken" env:"ADMIN_TOKEN"`
 }
 
 func LoadConfig() *Config {
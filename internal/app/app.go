@@ -1,22 +1,36 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/Verce11o/yata-notifications/config"
+	"github.com/Verce11o/yata-notifications/internal/digest"
+	"github.com/Verce11o/yata-notifications/internal/events"
+	"github.com/Verce11o/yata-notifications/internal/handler/admin"
+	"github.com/Verce11o/yata-notifications/internal/handler/gateway"
 	notificationGRPC "github.com/Verce11o/yata-notifications/internal/handler/grpc"
 	"github.com/Verce11o/yata-notifications/internal/handler/rabbitmq"
+	"github.com/Verce11o/yata-notifications/internal/health"
 	"github.com/Verce11o/yata-notifications/internal/lib/logger"
+	"github.com/Verce11o/yata-notifications/internal/metrics/prom"
 	"github.com/Verce11o/yata-notifications/internal/metrics/trace"
 	"github.com/Verce11o/yata-notifications/internal/repository/postgres"
 	"github.com/Verce11o/yata-notifications/internal/repository/redis"
 	"github.com/Verce11o/yata-notifications/internal/service"
 	pb "github.com/Verce11o/yata-protos/gen/go/notifications"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.opentelemetry.io/otel/propagation"
+	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	stdlog "log"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 )
 
@@ -32,19 +46,77 @@ func Run() {
 	rdb := redis.NewRedis(cfg)
 	redisRepo := redis.NewNotificationRedis(rdb, tracer.Tracer)
 
-	s := grpc.NewServer(grpc.UnaryInterceptor(otelgrpc.UnaryServerInterceptor(
-		otelgrpc.WithTracerProvider(tracer.Provider),
-		otelgrpc.WithPropagators(propagation.TraceContext{}),
-	)))
+	eventBus := events.NewRedisBus(rdb, log, tracer.Tracer)
+
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			otelgrpc.UnaryServerInterceptor(
+				otelgrpc.WithTracerProvider(tracer.Provider),
+				otelgrpc.WithPropagators(propagation.TraceContext{}),
+			),
+			prom.UnaryServerInterceptor(),
+		),
+		grpc.ChainStreamInterceptor(
+			otelgrpc.StreamServerInterceptor(
+				otelgrpc.WithTracerProvider(tracer.Provider),
+				otelgrpc.WithPropagators(propagation.TraceContext{}),
+			),
+			prom.StreamServerInterceptor(),
+		),
+	)
 
 	// Init broker
-	amqpConn := rabbitmq.NewAmqpConnection(cfg.RabbitMQ)
+	brokerCtx, stopBroker := context.WithCancel(context.Background())
+	defer stopBroker()
+
+	amqpConn := rabbitmq.NewConnectionManager(log, cfg.RabbitMQ)
+	go amqpConn.Run(brokerCtx)
 
-	notificationService := service.NewNotificationsService(log, tracer.Tracer, repo, redisRepo)
-	notificationConsumer := rabbitmq.NewNotificationConsumer(amqpConn, log, tracer.Tracer, notificationService)
+	notificationService := service.NewNotificationsService(log, tracer.Tracer, repo, redisRepo, eventBus, cfg.Notifications)
+	notificationConsumer := rabbitmq.NewNotificationConsumer(amqpConn, log, tracer.Tracer, notificationService, cfg.RabbitMQ)
 
 	pb.RegisterNotificationsServer(s, notificationGRPC.NewNotificationGRPC(log, tracer.Tracer, notificationService))
 
+	healthChecker := health.NewChecker(log, cfg.App.HealthCheckInterval, pb.Notifications_ServiceDesc.ServiceName)
+	healthChecker.Add("postgres", db.PingContext)
+	healthChecker.Add("redis", func(ctx context.Context) error {
+		return rdb.Ping(ctx).Err()
+	})
+	healthChecker.Add("rabbitmq", func(ctx context.Context) error {
+		if !amqpConn.Connected() {
+			return fmt.Errorf("amqp connection is %s", amqpConn.State())
+		}
+		return nil
+	})
+	healthChecker.Add("consumer", func(ctx context.Context) error {
+		if health := notificationConsumer.Health(); health.Alive == 0 {
+			return fmt.Errorf("%d of %d consumer workers alive", health.Alive, health.Size)
+		}
+		return nil
+	})
+	healthpb.RegisterHealthServer(s, healthChecker.Server())
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	healthChecker.Register(mux)
+
+	if cfg.Gateway.Enabled {
+		gw, err := gateway.NewGateway(log, tracer.Tracer, notificationService, cfg.Gateway)
+		if err != nil {
+			stdlog.Fatalf("error while creating gateway: %v", err)
+		}
+		gw.Register(mux)
+	}
+
+	if cfg.App.AdminToken != "" {
+		admin.NewHandler(log, cfg.App.AdminToken, notificationConsumer).Register(mux)
+	}
+
+	httpServer := &http.Server{
+		Addr:    fmt.Sprintf(":%s", cfg.App.HTTPPort),
+		Handler: mux,
+	}
+
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", cfg.App.Port))
 
 	if err != nil {
@@ -57,8 +129,15 @@ func Run() {
 		}
 	}()
 
+	go func() {
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Infof("error while listen http server: %s", err)
+		}
+	}()
+
 	go func() {
 		err := notificationConsumer.StartConsumer(
+			brokerCtx,
 			cfg.RabbitMQ.QueueName,
 			cfg.RabbitMQ.ConsumerTag,
 			cfg.RabbitMQ.ExchangeName,
@@ -71,6 +150,34 @@ func Run() {
 
 	}()
 
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	defer stopScheduler()
+
+	var schedulers sync.WaitGroup
+	runScheduled := func(run func(ctx context.Context)) {
+		schedulers.Add(1)
+		go func() {
+			defer schedulers.Done()
+			run(schedulerCtx)
+		}()
+	}
+
+	runScheduled(healthChecker.Run)
+	runScheduled(notificationService.RunDeferredDelivery)
+	runScheduled(service.NewCountsReconciler(log, tracer.Tracer, repo, cfg.Notifications.Counts).Run)
+
+	if cfg.Digest.Enabled {
+		digestService := service.NewDigestService(log, tracer.Tracer, repo, newDigestRenderer(cfg.Digest), newDigestSender(log, cfg.Digest), cfg.Digest)
+		runScheduled(digestService.Run)
+	}
+
+	var outboxPublisher *rabbitmq.OutboxPublisher
+
+	if cfg.Outbox.Enabled {
+		outboxPublisher = rabbitmq.NewOutboxPublisher(amqpConn, log, cfg.Outbox)
+		runScheduled(service.NewOutboxRelay(log, tracer.Tracer, repo, outboxPublisher, cfg.Outbox).Run)
+	}
+
 	log.Info(fmt.Sprintf("server listening at %s", lis.Addr().String()))
 
 	defer log.Sync()
@@ -79,10 +186,93 @@ func Run() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	s.GracefulStop()
+	log.Info("shutting down")
+
+	traceFlushTimeout := cfg.App.TraceFlushTimeout
+	if traceFlushTimeout <= 0 || traceFlushTimeout >= cfg.App.ShutdownTimeout {
+		traceFlushTimeout = cfg.App.ShutdownTimeout / 6
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.App.ShutdownTimeout-traceFlushTimeout)
+	defer cancel()
+
+	// Report NOT_SERVING first, so traffic is drained away while the rest shuts down.
+	healthChecker.Shutdown()
+
+	if err := notificationConsumer.Shutdown(ctx); err != nil {
+		log.Infof("error while drain consumer: %s", err)
+	}
+
+	stopScheduler()
+	waitOrDone(ctx, schedulers.Wait)
+
+	// Closing the bus ends live streams, which GracefulStop would otherwise wait for.
+	if err := eventBus.Close(); err != nil {
+		log.Infof("error while close event bus: %s", err)
+	}
+
+	if err := httpServer.Shutdown(ctx); err != nil {
+		log.Infof("error while shutdown http server: %s", err)
+	}
+
+	if !waitOrDone(ctx, s.GracefulStop) {
+		log.Info("grpc server did not stop in time, closing remaining connections")
+		s.Stop()
+	}
+
+	if outboxPublisher != nil {
+		outboxPublisher.Close()
+	}
+
+	stopBroker()
+	waitOrDone(ctx, func() {
+		<-amqpConn.Done()
+	})
+
+	if err := rdb.Close(); err != nil {
+		log.Infof("error while close redis: %s", err)
+	}
 
 	if err := db.Close(); err != nil {
 		log.Infof("error while close db: %s", err)
 	}
 
+	// The flush has its own deadline, so it runs even when the steps above used up theirs.
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), traceFlushTimeout)
+	defer cancelFlush()
+
+	if err := tracer.Provider.Shutdown(flushCtx); err != nil {
+		log.Infof("error while flush traces: %s", err)
+	}
+
+}
+
+func waitOrDone(ctx context.Context, wait func()) bool {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		wait()
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func newDigestRenderer(cfg config.Digest) *digest.Renderer {
+	renderer, err := digest.NewRenderer(cfg.Template)
+	if err != nil {
+		stdlog.Fatalf("error while parsing digest template: %v", err)
+	}
+	return renderer
+}
+
+func newDigestSender(log *zap.SugaredLogger, cfg config.Digest) digest.Sender {
+	if cfg.Sender == "smtp" {
+		return digest.NewSMTPSender(cfg.SMTP)
+	}
+	return digest.NewLogSender(log, cfg.OutputDir)
 }
@@ -0,0 +1,63 @@
+package digest
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"github.com/Verce11o/yata-notifications/internal/domain"
+	"text/template"
+)
+
+//go:embed templates/digest.tmpl
+var templates embed.FS
+
+type Document struct {
+	UserID  string
+	To      string
+	Subject string
+	Body    string
+}
+
+type Sender interface {
+	Send(ctx context.Context, document Document) error
+}
+
+type Renderer struct {
+	template *template.Template
+}
+
+func NewRenderer(path string) (*Renderer, error) {
+	var tmpl *template.Template
+	var err error
+
+	if path == "" {
+		tmpl, err = template.ParseFS(templates, "templates/digest.tmpl")
+	} else {
+		tmpl, err = template.ParseFiles(path)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Renderer{template: tmpl}, nil
+}
+
+func (r *Renderer) Render(digest domain.Digest) (Document, error) {
+	var subject, body bytes.Buffer
+
+	if err := r.template.ExecuteTemplate(&subject, "subject", digest); err != nil {
+		return Document{}, err
+	}
+
+	if err := r.template.ExecuteTemplate(&body, "body", digest); err != nil {
+		return Document{}, err
+	}
+
+	return Document{
+		UserID:  digest.UserID,
+		To:      digest.Email,
+		Subject: subject.String(),
+		Body:    body.String(),
+	}, nil
+}
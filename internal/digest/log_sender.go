@@ -0,0 +1,36 @@
+package digest
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type LogSender struct {
+	log *zap.SugaredLogger
+	dir string
+}
+
+func NewLogSender(log *zap.SugaredLogger, dir string) *LogSender {
+	return &LogSender{log: log, dir: dir}
+}
+
+func (s *LogSender) Send(ctx context.Context, document Document) error {
+	s.log.Infof("digest for %s <%s>: %s\n%s", document.UserID, document.To, document.Subject, document.Body)
+
+	if s.dir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return err
+	}
+
+	name := filepath.Join(s.dir, fmt.Sprintf("%s-%d.txt", document.UserID, time.Now().Unix()))
+	content := fmt.Sprintf("To: %s\nSubject: %s\n\n%s", document.To, document.Subject, document.Body)
+
+	return os.WriteFile(name, []byte(content), 0o644)
+}
@@ -0,0 +1,129 @@
+package digest
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"github.com/Verce11o/yata-notifications/config"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+const defaultSMTPTimeout = 30 * time.Second
+
+type SMTPSender struct {
+	host    string
+	addr    string
+	auth    smtp.Auth
+	from    string
+	timeout time.Duration
+}
+
+func NewSMTPSender(cfg config.SMTP) *SMTPSender {
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultSMTPTimeout
+	}
+
+	return &SMTPSender{
+		host:    cfg.Host,
+		addr:    fmt.Sprintf("%s:%s", cfg.Host, cfg.Port),
+		auth:    auth,
+		from:    cfg.From,
+		timeout: timeout,
+	}
+}
+
+func (s *SMTPSender) Send(ctx context.Context, document Document) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var message strings.Builder
+	fmt.Fprintf(&message, "From: %s\r\n", s.from)
+	fmt.Fprintf(&message, "To: %s\r\n", document.To)
+	fmt.Fprintf(&message, "Subject: %s\r\n", document.Subject)
+	message.WriteString("MIME-Version: 1.0\r\n")
+	message.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n\r\n")
+	message.WriteString(strings.ReplaceAll(document.Body, "\n", "\r\n"))
+
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+	defer stop()
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if err := s.deliver(client, document.To, message.String()); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *SMTPSender) deliver(client *smtp.Client, to string, message string) error {
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return err
+		}
+	}
+
+	if s.auth != nil {
+		if ok, _ := client.Extension("AUTH"); ok {
+			if err := client.Auth(s.auth); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := client.Mail(s.from); err != nil {
+		return err
+	}
+
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write([]byte(message)); err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
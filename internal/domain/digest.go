@@ -0,0 +1,33 @@
+package domain
+
+import "time"
+
+const (
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+type DigestSubscription struct {
+	UserID     string    `json:"user_id" db:"user_id"`
+	Email      string    `json:"email" db:"email"`
+	Frequency  string    `json:"frequency" db:"frequency"`
+	LastSentAt time.Time `json:"last_sent_at" db:"last_sent_at"`
+}
+
+type Digest struct {
+	UserID    string
+	Email     string
+	Frequency string
+	Since     time.Time
+	Until     time.Time
+	Total     int
+	Capped    bool
+	Groups    []DigestGroup
+}
+
+type DigestGroup struct {
+	Type     string
+	SenderID string
+	Count    int
+	LatestAt time.Time
+}
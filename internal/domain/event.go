@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+const (
+	EventNotificationCreated = "notification_created"
+	EventUnreadCountChanged  = "unread_count_changed"
+)
+
+type Event struct {
+	ID           string        `json:"id,omitempty"`
+	Type         string        `json:"type"`
+	UserID       string        `json:"user_id"`
+	Notification *Notification `json:"notification,omitempty"`
+	UnreadCount  *int64        `json:"unread_count,omitempty"`
+}
+
+type DeferredEvent struct {
+	Event     Event
+	ReleaseAt time.Time
+}
@@ -2,19 +2,41 @@ package domain
 
 import (
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx/types"
+	"github.com/lib/pq"
 	"time"
 )
 
 type Notification struct {
-	NotificationID uuid.UUID `json:"notification_id" db:"notification_id"`
-	ToUserID       uuid.UUID `json:"to_user_id" db:"to_user_id"`
-	FromUserID     uuid.UUID `json:"from_user_id,omitempty" db:"from_user_id"`
-	Type           string    `json:"type" db:"type"`
-	Read           bool      `json:"read" db:"read"`
-	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	NotificationID uuid.UUID      `json:"notification_id" db:"notification_id"`
+	ToUserID       uuid.UUID      `json:"to_user_id" db:"to_user_id"`
+	FromUserID     uuid.UUID      `json:"from_user_id,omitempty" db:"from_user_id"`
+	Type           string         `json:"type" db:"type"`
+	EntityKind     string         `json:"entity_kind,omitempty" db:"entity_kind"`
+	EntityID       string         `json:"entity_id,omitempty" db:"entity_id"`
+	Metadata       types.JSONText `json:"metadata,omitempty" db:"metadata"`
+	Read           bool           `json:"read" db:"read"`
+	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
+}
+
+type Entity struct {
+	Kind string `json:"kind"`
+	ID   string `json:"id"`
 }
 
 type IncomingNewNotification struct {
-	SenderID uuid.UUID `json:"sender_id"`
-	Type     string    `json:"type"`
+	EventID  string         `json:"event_id,omitempty"`
+	SenderID uuid.UUID      `json:"sender_id"`
+	Type     string         `json:"type"`
+	Entity   Entity         `json:"entity"`
+	Metadata types.JSONText `json:"metadata,omitempty"`
+	GroupKey string         `json:"-"`
+	DedupKey string         `json:"-"`
+}
+
+type NotificationGroup struct {
+	Notification
+	Count        int64          `json:"count" db:"count"`
+	ActorCount   int64          `json:"actor_count" db:"actor_count"`
+	RecentActors pq.StringArray `json:"recent_actors" db:"recent_actors"`
 }
@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx/types"
+	"time"
+)
+
+const (
+	OutboxNotificationCreated = "notification.created"
+	OutboxAuthorFeedCreated   = "author_feed.created"
+)
+
+type OutboxMessage struct {
+	ID        uuid.UUID      `json:"id" db:"id"`
+	EventType string         `json:"event_type" db:"event_type"`
+	Payload   types.JSONText `json:"payload" db:"payload"`
+	Attempts  int            `json:"attempts" db:"attempts"`
+	CreatedAt time.Time      `json:"created_at" db:"created_at"`
+}
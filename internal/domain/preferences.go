@@ -0,0 +1,17 @@
+package domain
+
+import (
+	"github.com/lib/pq"
+	"time"
+)
+
+type Preferences struct {
+	UserID          string         `json:"user_id" db:"user_id"`
+	DisabledTypes   pq.StringArray `json:"disabled_types" db:"disabled_types"`
+	MutedSenders    pq.StringArray `json:"muted_senders" db:"muted_senders"`
+	MutedUntil      *time.Time     `json:"muted_until,omitempty" db:"muted_until"`
+	QuietHoursStart string         `json:"quiet_hours_start,omitempty" db:"quiet_hours_start"`
+	QuietHoursEnd   string         `json:"quiet_hours_end,omitempty" db:"quiet_hours_end"`
+	Timezone        string         `json:"timezone" db:"timezone"`
+	UpdatedAt       time.Time      `json:"updated_at" db:"updated_at"`
+}
@@ -1,11 +1,51 @@
 package domain
 
-import "time"
+import (
+	"github.com/lib/pq"
+	"slices"
+	"time"
+)
+
+type SubscriptionLevel string
+
+const (
+	SubscriptionLevelAll   SubscriptionLevel = "all"
+	SubscriptionLevelTypes SubscriptionLevel = "types"
+	SubscriptionLevelNone  SubscriptionLevel = "none"
+)
 
 type Subscriber struct {
-	ID        string    `json:"id" db:"id"`
-	UserID    string    `json:"userID" db:"user_id"`
-	ToUserID  string    `json:"toUserID" db:"to_user_id"`
-	CreatedAt time.Time `json:"createdAt" db:"created_at"`
-	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+	ID        string            `json:"id" db:"id"`
+	UserID    string            `json:"userID" db:"user_id"`
+	ToUserID  string            `json:"toUserID" db:"to_user_id"`
+	CreatedAt time.Time         `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time         `json:"updatedAt" db:"updated_at"`
+	Level     SubscriptionLevel `json:"level,omitempty" db:"level"`
+	Types     pq.StringArray    `json:"types,omitempty" db:"types"`
+}
+
+func (s Subscriber) Receives(notificationType string) bool {
+	switch s.Level {
+	case SubscriptionLevelNone:
+		return false
+	case SubscriptionLevelTypes:
+		return slices.Contains(s.Types, notificationType)
+	}
+	return true
+}
+
+type SubscriptionCounts struct {
+	UserID    string `json:"userID" db:"user_id"`
+	Followers int64  `json:"followers" db:"followers"`
+	Following int64  `json:"following" db:"following"`
+}
+
+type Relationship struct {
+	UserID     string `json:"userID" db:"user_id"`
+	Following  bool   `json:"following" db:"following"`
+	FollowedBy bool   `json:"followedBy" db:"followed_by"`
+}
+
+func (r Relationship) Mutual() bool {
+	return r.Following && r.FollowedBy
 }
@@ -0,0 +1,14 @@
+package events
+
+import (
+	"context"
+	"github.com/Verce11o/yata-notifications/internal/domain"
+)
+
+// Bus closes the channel of a subscriber that falls behind; it has to resubscribe.
+type Bus interface {
+	Publish(ctx context.Context, events ...domain.Event) error
+	Subscribe(ctx context.Context, userID string) (<-chan domain.Event, error)
+	PublishFeed(ctx context.Context, authorID string, event domain.Event) error
+	SubscribeFeed(ctx context.Context, authorID string) (<-chan domain.Event, error)
+}
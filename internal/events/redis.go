@@ -0,0 +1,225 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/Verce11o/yata-notifications/internal/domain"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"sync"
+)
+
+const (
+	channelPrefix        = "notification_events:"
+	feedChannelPrefix    = "author_feed_events:"
+	subscriberBufferSize = 64
+)
+
+type RedisBus struct {
+	client *redis.Client
+	pubsub *redis.PubSub
+	log    *zap.SugaredLogger
+	tracer trace.Tracer
+
+	mu          sync.Mutex
+	subscribers map[string]map[chan domain.Event]struct{}
+}
+
+func NewRedisBus(client *redis.Client, log *zap.SugaredLogger, tracer trace.Tracer) *RedisBus {
+	b := &RedisBus{
+		client:      client,
+		pubsub:      client.Subscribe(context.Background()),
+		log:         log,
+		tracer:      tracer,
+		subscribers: make(map[string]map[chan domain.Event]struct{}),
+	}
+
+	go b.dispatch()
+
+	return b
+}
+
+func (b *RedisBus) Publish(ctx context.Context, events ...domain.Event) error {
+	ctx, span := b.tracer.Start(ctx, "redisBus.Publish")
+	defer span.End()
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	pipe := b.client.Pipeline()
+
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		pipe.Publish(ctx, b.createChannel(event.UserID), payload)
+	}
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (b *RedisBus) Subscribe(ctx context.Context, userID string) (<-chan domain.Event, error) {
+	return b.subscribe(ctx, b.createChannel(userID))
+}
+
+func (b *RedisBus) PublishFeed(ctx context.Context, authorID string, event domain.Event) error {
+	ctx, span := b.tracer.Start(ctx, "redisBus.PublishFeed")
+	defer span.End()
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return b.client.Publish(ctx, b.createFeedChannel(authorID), payload).Err()
+}
+
+func (b *RedisBus) SubscribeFeed(ctx context.Context, authorID string) (<-chan domain.Event, error) {
+	return b.subscribe(ctx, b.createFeedChannel(authorID))
+}
+
+func (b *RedisBus) subscribe(ctx context.Context, channel string) (<-chan domain.Event, error) {
+	ch := make(chan domain.Event, subscriberBufferSize)
+
+	b.mu.Lock()
+	subs, ok := b.subscribers[channel]
+	if !ok {
+		subs = make(map[chan domain.Event]struct{})
+		b.subscribers[channel] = subs
+	}
+	subs[ch] = struct{}{}
+	b.mu.Unlock()
+
+	if !ok {
+		if err := b.pubsub.Subscribe(ctx, channel); err != nil {
+			b.dropChannel(channel)
+			return nil, err
+		}
+	}
+
+	go func() {
+		<-ctx.Done()
+		b.unsubscribe(channel, ch)
+	}()
+
+	return ch, nil
+}
+
+func (b *RedisBus) dropChannel(channel string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers[channel] {
+		close(ch)
+	}
+	delete(b.subscribers, channel)
+}
+
+func (b *RedisBus) Close() error {
+	return b.pubsub.Close()
+}
+
+func (b *RedisBus) unsubscribe(channel string, ch chan domain.Event) {
+	if b.removeSubscriber(channel, ch) {
+		b.leave(channel)
+	}
+}
+
+func (b *RedisBus) leave(channel string) {
+	ctx := context.Background()
+
+	if err := b.pubsub.Unsubscribe(ctx, channel); err != nil {
+		b.log.Errorf("cannot unsubscribe from %s: %v", channel, err)
+	}
+
+	// A subscribe racing the unsubscribe may have been undone; Redis ignores duplicates.
+	b.mu.Lock()
+	_, resubscribe := b.subscribers[channel]
+	b.mu.Unlock()
+
+	if resubscribe {
+		if err := b.pubsub.Subscribe(ctx, channel); err != nil {
+			b.log.Errorf("cannot subscribe to %s: %v", channel, err)
+		}
+	}
+}
+
+func (b *RedisBus) removeSubscriber(channel string, ch chan domain.Event) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subs, ok := b.subscribers[channel]
+	if !ok {
+		return false
+	}
+
+	if _, ok := subs[ch]; !ok {
+		return false
+	}
+
+	delete(subs, ch)
+	close(ch)
+
+	if len(subs) > 0 {
+		return false
+	}
+
+	delete(b.subscribers, channel)
+	return true
+}
+
+func (b *RedisBus) dispatch() {
+	for message := range b.pubsub.Channel() {
+		var event domain.Event
+
+		if err := json.Unmarshal([]byte(message.Payload), &event); err != nil {
+			b.log.Errorf("cannot unmarshal event: %v", err)
+			continue
+		}
+
+		b.mu.Lock()
+		subs := b.subscribers[message.Channel]
+		for ch := range subs {
+			select {
+			case ch <- event:
+			default:
+				// The subscriber resyncs instead of silently losing the event.
+				b.log.Warnf("closing slow subscriber of %s", message.Channel)
+				delete(subs, ch)
+				close(ch)
+			}
+		}
+		left := subs != nil && len(subs) == 0
+		if left {
+			delete(b.subscribers, message.Channel)
+		}
+		b.mu.Unlock()
+
+		if left {
+			go b.leave(message.Channel)
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for channel, subs := range b.subscribers {
+		for ch := range subs {
+			close(ch)
+		}
+		delete(b.subscribers, channel)
+	}
+}
+
+func (b *RedisBus) createChannel(userID string) string {
+	return fmt.Sprintf("%s%s", channelPrefix, userID)
+}
+
+func (b *RedisBus) createFeedChannel(authorID string) string {
+	return fmt.Sprintf("%s%s", feedChannelPrefix, authorID)
+}
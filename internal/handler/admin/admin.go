@@ -0,0 +1,93 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"github.com/Verce11o/yata-notifications/internal/handler/rabbitmq"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultRedriveLimit = 100
+)
+
+type Consumer interface {
+	RedriveDeadLetters(ctx context.Context, limit int) (int, error)
+	Health() rabbitmq.PoolHealth
+	ConnectionState() rabbitmq.ConnectionState
+}
+
+type Handler struct {
+	log      *zap.SugaredLogger
+	token    string
+	consumer Consumer
+}
+
+func NewHandler(log *zap.SugaredLogger, token string, consumer Consumer) *Handler {
+	return &Handler{log: log, token: token, consumer: consumer}
+}
+
+func (h *Handler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/admin/dlq/redrive", h.authorize(h.Redrive))
+	mux.HandleFunc("/admin/consumer/health", h.authorize(h.ConsumerHealth))
+}
+
+func (h *Handler) Redrive(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := defaultRedriveLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	redriven, err := h.consumer.RedriveDeadLetters(r.Context(), limit)
+	if err != nil {
+		h.log.Errorf("Redrive: %v", err.Error())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string]any{"redriven": redriven})
+}
+
+func (h *Handler) ConsumerHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	health := h.consumer.Health()
+	state := h.consumer.ConnectionState()
+
+	w.Header().Set("Content-Type", "application/json")
+	if !health.Healthy() || state != rabbitmq.StateConnected {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string]any{"connection": state.String(), "pool": health})
+}
+
+func (h *Handler) authorize(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		expected := "Bearer " + h.token
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(expected)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
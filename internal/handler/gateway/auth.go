@@ -0,0 +1,74 @@
+package gateway
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errUnauthorized = errors.New("invalid or expired token")
+
+func (g *Gateway) authenticate(r *http.Request) (string, error) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		token = r.URL.Query().Get("access_token")
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] == "" {
+		return "", errUnauthorized
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, g.sign(parts[0]+"."+parts[1])) {
+		return "", errUnauthorized
+	}
+
+	expiry, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || time.Now().Unix() >= expiry {
+		return "", errUnauthorized
+	}
+
+	return parts[0], nil
+}
+
+func (g *Gateway) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, []byte(g.cfg.TokenSecret))
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+func (g *Gateway) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	for _, allowed := range g.cfg.AllowedOrigins {
+		if strings.EqualFold(origin, allowed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (g *Gateway) authorize(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if !g.checkOrigin(r) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return "", false
+	}
+
+	userID, err := g.authenticate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return "", false
+	}
+
+	return userID, true
+}
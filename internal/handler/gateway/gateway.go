@@ -0,0 +1,75 @@
+package gateway
+
+import (
+	"fmt"
+	"github.com/Verce11o/yata-notifications/config"
+	"github.com/Verce11o/yata-notifications/internal/lib/grpc_errors"
+	"github.com/Verce11o/yata-notifications/internal/service"
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"net/http"
+	"time"
+)
+
+const (
+	heartbeatInterval = 25 * time.Second
+	writeTimeout      = 10 * time.Second
+	pongTimeout       = 2 * heartbeatInterval
+	retryInterval     = 3 * time.Second
+	minSecretLength   = 32
+)
+
+type Gateway struct {
+	log      *zap.SugaredLogger
+	tracer   trace.Tracer
+	service  service.Notifications
+	cfg      config.Gateway
+	upgrader websocket.Upgrader
+}
+
+func NewGateway(log *zap.SugaredLogger, tracer trace.Tracer, service service.Notifications, cfg config.Gateway) (*Gateway, error) {
+	if len(cfg.TokenSecret) < minSecretLength {
+		return nil, fmt.Errorf("token secret must be at least %d bytes long", minSecretLength)
+	}
+
+	g := &Gateway{
+		log:     log,
+		tracer:  tracer,
+		service: service,
+		cfg:     cfg,
+	}
+
+	g.upgrader = websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin:     g.checkOrigin,
+	}
+
+	return g, nil
+}
+
+func (g *Gateway) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/notifications/ws", g.ServeWebSocket)
+	mux.HandleFunc("/notifications/sse", g.ServeSSE)
+}
+
+func lastEventID(r *http.Request) string {
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		return id
+	}
+	return r.URL.Query().Get("last_event_id")
+}
+
+func httpStatusCode(err error) int {
+	switch grpc_errors.ParseGRPCErrStatusCode(err) {
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,76 @@
+package gateway
+
+import (
+	"github.com/gorilla/websocket"
+	"net/http"
+	"time"
+)
+
+func (g *Gateway) ServeWebSocket(w http.ResponseWriter, r *http.Request) {
+	ctx, span := g.tracer.Start(r.Context(), "Gateway.ServeWebSocket")
+	defer span.End()
+
+	userID, ok := g.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	events, err := g.service.SubscribeEvents(ctx, userID, lastEventID(r))
+	if err != nil {
+		g.log.Errorf("ServeWebSocket: %v", err.Error())
+		http.Error(w, err.Error(), httpStatusCode(err))
+		return
+	}
+
+	conn, err := g.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		g.log.Errorf("cannot upgrade connection: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	closed := make(chan struct{})
+	go g.readPump(conn, closed)
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-closed:
+			return
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				_ = conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseGoingAway, "resync"), time.Now().Add(writeTimeout))
+				return
+			}
+
+			_ = conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (g *Gateway) readPump(conn *websocket.Conn, closed chan<- struct{}) {
+	defer close(closed)
+
+	_ = conn.SetReadDeadline(time.Now().Add(pongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongTimeout))
+	})
+
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
@@ -2,6 +2,7 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"github.com/Verce11o/yata-notifications/internal/lib/grpc_errors"
 	"github.com/Verce11o/yata-notifications/internal/service"
 	pb "github.com/Verce11o/yata-protos/gen/go/notifications"
@@ -35,6 +36,20 @@ func (n *NotificationGRPC) SubscribeToUser(ctx context.Context, input *pb.Subscr
 	return &pb.SubscribeToUserResponse{}, nil
 }
 
+func (n *NotificationGRPC) UpdateSubscriptionLevel(ctx context.Context, input *pb.UpdateSubscriptionLevelRequest) (*pb.UpdateSubscriptionLevelResponse, error) {
+	ctx, span := n.tracer.Start(ctx, "GRPC.UpdateSubscriptionLevel")
+	defer span.End()
+
+	err := n.service.UpdateSubscriptionLevel(ctx, input)
+
+	if err != nil {
+		n.log.Errorf("UpdateSubscriptionLevel: %v", err.Error())
+		return nil, status.Errorf(grpc_errors.ParseGRPCErrStatusCode(err), "UpdateSubscriptionLevel: %v", err)
+	}
+
+	return &pb.UpdateSubscriptionLevelResponse{}, nil
+}
+
 func (n *NotificationGRPC) UnSubscribeFromUser(ctx context.Context, input *pb.UnSubscribeFromUserRequest) (*pb.UnSubscribeFromUserResponse, error) {
 	ctx, span := n.tracer.Start(ctx, "GRPC.UnSubscribeFromUser")
 	defer span.End()
@@ -68,18 +83,91 @@ func (n *NotificationGRPC) GetUserSubscriptions(ctx context.Context, input *pb.G
 
 }
 
+func (n *NotificationGRPC) GetUserSubscribers(ctx context.Context, input *pb.GetUserSubscribersRequest) (*pb.GetUserSubscribersResponse, error) {
+	ctx, span := n.tracer.Start(ctx, "GRPC.GetUserSubscribers")
+	defer span.End()
+
+	subscribers, cursor, err := n.service.GetUserSubscribers(ctx, input.GetUserId(), input.GetCursor())
+
+	if err != nil {
+		n.log.Errorf("GetUserSubscribers: %v", err.Error())
+		return nil, status.Errorf(grpc_errors.ParseGRPCErrStatusCode(err), "GetUserSubscribers: %v", err)
+	}
+
+	return &pb.GetUserSubscribersResponse{
+		Subscribers: subscribers,
+		Cursor:      cursor,
+	}, nil
+
+}
+
+func (n *NotificationGRPC) GetSubscriptionCounts(ctx context.Context, input *pb.GetSubscriptionCountsRequest) (*pb.GetSubscriptionCountsResponse, error) {
+	ctx, span := n.tracer.Start(ctx, "GRPC.GetSubscriptionCounts")
+	defer span.End()
+
+	counts, err := n.service.GetSubscriptionCounts(ctx, input.GetUserIds())
+
+	if err != nil {
+		n.log.Errorf("GetSubscriptionCounts: %v", err.Error())
+		return nil, status.Errorf(grpc_errors.ParseGRPCErrStatusCode(err), "GetSubscriptionCounts: %v", err)
+	}
+
+	return &pb.GetSubscriptionCountsResponse{
+		Counts: counts,
+	}, nil
+
+}
+
+func (n *NotificationGRPC) GetRelationships(ctx context.Context, input *pb.GetRelationshipsRequest) (*pb.GetRelationshipsResponse, error) {
+	ctx, span := n.tracer.Start(ctx, "GRPC.GetRelationships")
+	defer span.End()
+
+	relationships, err := n.service.GetRelationships(ctx, input.GetUserId(), input.GetTargetUserIds())
+
+	if err != nil {
+		n.log.Errorf("GetRelationships: %v", err.Error())
+		return nil, status.Errorf(grpc_errors.ParseGRPCErrStatusCode(err), "GetRelationships: %v", err)
+	}
+
+	return &pb.GetRelationshipsResponse{
+		Relationships: relationships,
+	}, nil
+
+}
+
+func (n *NotificationGRPC) GetMutualFollowers(ctx context.Context, input *pb.GetMutualFollowersRequest) (*pb.GetMutualFollowersResponse, error) {
+	ctx, span := n.tracer.Start(ctx, "GRPC.GetMutualFollowers")
+	defer span.End()
+
+	subscribers, cursor, err := n.service.GetMutualFollowers(ctx, input.GetUserId(), input.GetCursor())
+
+	if err != nil {
+		n.log.Errorf("GetMutualFollowers: %v", err.Error())
+		return nil, status.Errorf(grpc_errors.ParseGRPCErrStatusCode(err), "GetMutualFollowers: %v", err)
+	}
+
+	return &pb.GetMutualFollowersResponse{
+		Subscribers: subscribers,
+		Cursor:      cursor,
+	}, nil
+
+}
+
 func (n *NotificationGRPC) GetNotifications(ctx context.Context, input *pb.GetNotificationsRequest) (*pb.GetNotificationsResponse, error) {
 	ctx, span := n.tracer.Start(ctx, "GRPC.GetNotifications")
 	defer span.End()
 
-	notifications, err := n.service.GetNotifications(ctx, input.GetUserId())
+	notifications, cursor, err := n.service.GetNotifications(ctx, input.GetUserId(), input.GetCursor(), int(input.GetLimit()))
 
 	if err != nil {
 		n.log.Errorf("GetNotifications: %v", err.Error())
-		return nil, status.Errorf(grpc_errors.ParseGRPCErrStatusCode(err), "UnSubscribeFromUser: %v", err)
+		return nil, status.Errorf(grpc_errors.ParseGRPCErrStatusCode(err), "GetNotifications: %v", err)
 	}
 
-	return &pb.GetNotificationsResponse{Notifications: notifications}, nil
+	return &pb.GetNotificationsResponse{
+		Notifications: notifications,
+		Cursor:        cursor,
+	}, nil
 
 }
 
@@ -111,3 +199,31 @@ func (n *NotificationGRPC) ReadAllNotifications(ctx context.Context, input *pb.R
 	return &pb.ReadAllNotificationsResponse{}, nil
 
 }
+
+func (n *NotificationGRPC) GetUnreadCount(ctx context.Context, input *pb.GetUnreadCountRequest) (*pb.GetUnreadCountResponse, error) {
+	ctx, span := n.tracer.Start(ctx, "GRPC.GetUnreadCount")
+	defer span.End()
+
+	count, err := n.service.GetUnreadCount(ctx, input.GetUserId())
+
+	if err != nil {
+		n.log.Errorf("GetUnreadCount: %v", err.Error())
+		return nil, status.Errorf(grpc_errors.ParseGRPCErrStatusCode(err), "GetUnreadCount: %v", err)
+	}
+
+	return &pb.GetUnreadCountResponse{Count: count}, nil
+}
+
+func (n *NotificationGRPC) StreamNotifications(input *pb.StreamNotificationsRequest, stream pb.Notifications_StreamNotificationsServer) error {
+	ctx, span := n.tracer.Start(stream.Context(), "GRPC.StreamNotifications")
+	defer span.End()
+
+	err := n.service.StreamNotifications(ctx, input.GetUserId(), stream.Send)
+
+	if err != nil && !errors.Is(err, context.Canceled) {
+		n.log.Errorf("StreamNotifications: %v", err.Error())
+		return status.Errorf(grpc_errors.ParseGRPCErrStatusCode(err), "StreamNotifications: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+package grpc
+
+import (
+	"context"
+	"github.com/Verce11o/yata-notifications/internal/lib/grpc_errors"
+	pb "github.com/Verce11o/yata-protos/gen/go/notifications"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+func (n *NotificationGRPC) GetNotificationPreferences(ctx context.Context, input *pb.GetNotificationPreferencesRequest) (*pb.GetNotificationPreferencesResponse, error) {
+	ctx, span := n.tracer.Start(ctx, "GRPC.GetNotificationPreferences")
+	defer span.End()
+
+	preferences, err := n.service.GetPreferences(ctx, input.GetUserId())
+
+	if err != nil {
+		n.log.Errorf("GetNotificationPreferences: %v", err.Error())
+		return nil, status.Errorf(grpc_errors.ParseGRPCErrStatusCode(err), "GetNotificationPreferences: %v", err)
+	}
+
+	return &pb.GetNotificationPreferencesResponse{Preferences: preferences}, nil
+}
+
+func (n *NotificationGRPC) UpdateNotificationPreferences(ctx context.Context, input *pb.UpdateNotificationPreferencesRequest) (*pb.UpdateNotificationPreferencesResponse, error) {
+	ctx, span := n.tracer.Start(ctx, "GRPC.UpdateNotificationPreferences")
+	defer span.End()
+
+	preferences, err := n.service.UpdatePreferences(ctx, input)
+
+	if err != nil {
+		n.log.Errorf("UpdateNotificationPreferences: %v", err.Error())
+		return nil, status.Errorf(grpc_errors.ParseGRPCErrStatusCode(err), "UpdateNotificationPreferences: %v", err)
+	}
+
+	return &pb.UpdateNotificationPreferencesResponse{Preferences: preferences}, nil
+}
+
+func (n *NotificationGRPC) DeleteNotificationPreferences(ctx context.Context, input *pb.DeleteNotificationPreferencesRequest) (*pb.DeleteNotificationPreferencesResponse, error) {
+	ctx, span := n.tracer.Start(ctx, "GRPC.DeleteNotificationPreferences")
+	defer span.End()
+
+	err := n.service.DeletePreferences(ctx, input.GetUserId())
+
+	if err != nil {
+		n.log.Errorf("DeleteNotificationPreferences: %v", err.Error())
+		return nil, status.Errorf(grpc_errors.ParseGRPCErrStatusCode(err), "DeleteNotificationPreferences: %v", err)
+	}
+
+	return &pb.DeleteNotificationPreferencesResponse{}, nil
+}
+
+func (n *NotificationGRPC) SetNotificationTypeEnabled(ctx context.Context, input *pb.SetNotificationTypeEnabledRequest) (*pb.SetNotificationTypeEnabledResponse, error) {
+	ctx, span := n.tracer.Start(ctx, "GRPC.SetNotificationTypeEnabled")
+	defer span.End()
+
+	err := n.service.SetNotificationTypeEnabled(ctx, input.GetUserId(), input.GetType(), input.GetEnabled())
+
+	if err != nil {
+		n.log.Errorf("SetNotificationTypeEnabled: %v", err.Error())
+		return nil, status.Errorf(grpc_errors.ParseGRPCErrStatusCode(err), "SetNotificationTypeEnabled: %v", err)
+	}
+
+	return &pb.SetNotificationTypeEnabledResponse{}, nil
+}
+
+func (n *NotificationGRPC) MuteSender(ctx context.Context, input *pb.MuteSenderRequest) (*pb.MuteSenderResponse, error) {
+	ctx, span := n.tracer.Start(ctx, "GRPC.MuteSender")
+	defer span.End()
+
+	err := n.service.MuteSender(ctx, input.GetUserId(), input.GetSenderId())
+
+	if err != nil {
+		n.log.Errorf("MuteSender: %v", err.Error())
+		return nil, status.Errorf(grpc_errors.ParseGRPCErrStatusCode(err), "MuteSender: %v", err)
+	}
+
+	return &pb.MuteSenderResponse{}, nil
+}
+
+func (n *NotificationGRPC) UnmuteSender(ctx context.Context, input *pb.UnmuteSenderRequest) (*pb.UnmuteSenderResponse, error) {
+	ctx, span := n.tracer.Start(ctx, "GRPC.UnmuteSender")
+	defer span.End()
+
+	err := n.service.UnmuteSender(ctx, input.GetUserId(), input.GetSenderId())
+
+	if err != nil {
+		n.log.Errorf("UnmuteSender: %v", err.Error())
+		return nil, status.Errorf(grpc_errors.ParseGRPCErrStatusCode(err), "UnmuteSender: %v", err)
+	}
+
+	return &pb.UnmuteSenderResponse{}, nil
+}
+
+func (n *NotificationGRPC) MuteAllNotifications(ctx context.Context, input *pb.MuteAllNotificationsRequest) (*pb.MuteAllNotificationsResponse, error) {
+	ctx, span := n.tracer.Start(ctx, "GRPC.MuteAllNotifications")
+	defer span.End()
+
+	var until *time.Time
+	if input.GetMutedUntil() != nil {
+		mutedUntil := input.GetMutedUntil().AsTime()
+		until = &mutedUntil
+	}
+
+	err := n.service.MuteAll(ctx, input.GetUserId(), until)
+
+	if err != nil {
+		n.log.Errorf("MuteAllNotifications: %v", err.Error())
+		return nil, status.Errorf(grpc_errors.ParseGRPCErrStatusCode(err), "MuteAllNotifications: %v", err)
+	}
+
+	return &pb.MuteAllNotificationsResponse{}, nil
+}
+
+func (n *NotificationGRPC) SetQuietHours(ctx context.Context, input *pb.SetQuietHoursRequest) (*pb.SetQuietHoursResponse, error) {
+	ctx, span := n.tracer.Start(ctx, "GRPC.SetQuietHours")
+	defer span.End()
+
+	err := n.service.SetQuietHours(ctx, input.GetUserId(), input.GetStart(), input.GetEnd(), input.GetTimezone())
+
+	if err != nil {
+		n.log.Errorf("SetQuietHours: %v", err.Error())
+		return nil, status.Errorf(grpc_errors.ParseGRPCErrStatusCode(err), "SetQuietHours: %v", err)
+	}
+
+	return &pb.SetQuietHoursResponse{}, nil
+}
+
+func (n *NotificationGRPC) SubscribeToDigest(ctx context.Context, input *pb.SubscribeToDigestRequest) (*pb.SubscribeToDigestResponse, error) {
+	ctx, span := n.tracer.Start(ctx, "GRPC.SubscribeToDigest")
+	defer span.End()
+
+	err := n.service.SubscribeToDigest(ctx, input.GetUserId(), input.GetFrequency(), input.GetEmail())
+
+	if err != nil {
+		n.log.Errorf("SubscribeToDigest: %v", err.Error())
+		return nil, status.Errorf(grpc_errors.ParseGRPCErrStatusCode(err), "SubscribeToDigest: %v", err)
+	}
+
+	return &pb.SubscribeToDigestResponse{}, nil
+}
+
+func (n *NotificationGRPC) UnsubscribeFromDigest(ctx context.Context, input *pb.UnsubscribeFromDigestRequest) (*pb.UnsubscribeFromDigestResponse, error) {
+	ctx, span := n.tracer.Start(ctx, "GRPC.UnsubscribeFromDigest")
+	defer span.End()
+
+	err := n.service.UnsubscribeFromDigest(ctx, input.GetUserId())
+
+	if err != nil {
+		n.log.Errorf("UnsubscribeFromDigest: %v", err.Error())
+		return nil, status.Errorf(grpc_errors.ParseGRPCErrStatusCode(err), "UnsubscribeFromDigest: %v", err)
+	}
+
+	return &pb.UnsubscribeFromDigestResponse{}, nil
+}
@@ -1,18 +1,187 @@
 package rabbitmq
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/Verce11o/yata-notifications/config"
 	amqp "github.com/rabbitmq/amqp091-go"
-	"log"
+	"go.uber.org/zap"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
-func NewAmqpConnection(cfg config.RabbitMQ) *amqp.Connection {
-	conn, err := amqp.Dial(fmt.Sprintf("amqp://%s:%s@%s:%s/", cfg.Username, cfg.Password, cfg.Host, cfg.Port))
-	if err != nil {
-		log.Fatalf("err while connection to amqp: %v", err.Error())
+var ErrConnectionClosed = errors.New("amqp connection manager is closed")
+
+const (
+	defaultReconnectBaseDelay = 500 * time.Millisecond
+	defaultReconnectMaxDelay  = 30 * time.Second
+)
+
+type ConnectionState int32
+
+const (
+	StateConnecting ConnectionState = iota
+	StateConnected
+	StateClosed
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateClosed:
+		return "closed"
+	}
+	return "unknown"
+}
+
+type ConnectionManager struct {
+	log *zap.SugaredLogger
+	cfg config.RabbitMQ
+
+	mu        sync.RWMutex
+	conn      *amqp.Connection
+	connected chan struct{}
+	done      chan struct{}
+
+	state      atomic.Int32
+	reconnects atomic.Int64
+}
+
+func NewConnectionManager(log *zap.SugaredLogger, cfg config.RabbitMQ) *ConnectionManager {
+	if cfg.ReconnectBaseDelay <= 0 {
+		cfg.ReconnectBaseDelay = defaultReconnectBaseDelay
+	}
+	if cfg.ReconnectMaxDelay < cfg.ReconnectBaseDelay {
+		cfg.ReconnectMaxDelay = max(cfg.ReconnectBaseDelay, defaultReconnectMaxDelay)
 	}
 
-	return conn
+	return &ConnectionManager{
+		log:       log,
+		cfg:       cfg,
+		connected: make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+func (m *ConnectionManager) Run(ctx context.Context) {
+	defer m.shutdown()
+
+	attempt := 0
+
+	for {
+		conn, err := amqp.Dial(fmt.Sprintf("amqp://%s:%s@%s:%s/", m.cfg.Username, m.cfg.Password, m.cfg.Host, m.cfg.Port))
+
+		if err != nil {
+			delay := jitteredBackoff(m.cfg.ReconnectBaseDelay, m.cfg.ReconnectMaxDelay, attempt)
+			attempt++
+			m.log.Errorf("cannot connect to amqp, retrying in %s: %v", delay, err.Error())
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+				continue
+			}
+		}
+
+		attempt = 0
+		closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+		m.setConnection(conn)
+		m.log.Info("amqp connection established")
+
+		select {
+		case <-ctx.Done():
+			return
+		case amqpErr := <-closed:
+			m.setConnection(nil)
+			m.reconnects.Add(1)
+			m.log.Errorf("amqp connection closed: %v", amqpErr)
+		}
+	}
+}
+
+func (m *ConnectionManager) Channel(ctx context.Context) (*amqp.Channel, error) {
+	m.mu.RLock()
+	conn, connected := m.conn, m.connected
+	m.mu.RUnlock()
+
+	if conn == nil {
+		select {
+		case <-connected:
+			return m.Channel(ctx)
+		case <-m.done:
+			return nil, ErrConnectionClosed
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	return conn.Channel()
+}
+
+func (m *ConnectionManager) Done() <-chan struct{} {
+	return m.done
+}
+
+func (m *ConnectionManager) State() ConnectionState {
+	return ConnectionState(m.state.Load())
+}
+
+func (m *ConnectionManager) Connected() bool {
+	return m.State() == StateConnected
+}
+
+func (m *ConnectionManager) Reconnects() int64 {
+	return m.reconnects.Load()
+}
+
+func (m *ConnectionManager) setConnection(conn *amqp.Connection) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.conn = conn
+
+	if conn == nil {
+		m.connected = make(chan struct{})
+		m.state.Store(int32(StateConnecting))
+		return
+	}
+
+	close(m.connected)
+	m.state.Store(int32(StateConnected))
+}
+
+func (m *ConnectionManager) shutdown() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.state.Store(int32(StateClosed))
+	close(m.done)
+
+	if m.conn != nil && !m.conn.IsClosed() {
+		if err := m.conn.Close(); err != nil {
+			m.log.Errorf("cannot close amqp connection: %v", err.Error())
+		}
+	}
+	m.conn = nil
+}
+
+func jitteredBackoff(base, max time.Duration, attempt int) time.Duration {
+	delay := max
+	if attempt < 32 && base<<attempt < max {
+		delay = base << attempt
+	}
+
+	if delay < 1 {
+		delay = 1
+	}
 
+	half := int64(delay / 2)
+	return time.Duration(half + rand.Int63n(half+1))
 }
@@ -0,0 +1,31 @@
+package rabbitmq
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJitteredBackoffStaysInRange(t *testing.T) {
+	tests := []struct {
+		name      string
+		base, max time.Duration
+		attempt   int
+		min, upTo time.Duration
+	}{
+		{name: "first attempt", base: time.Second, max: time.Minute, attempt: 0, min: time.Second / 2, upTo: time.Second},
+		{name: "capped", base: time.Second, max: time.Minute, attempt: 40, min: time.Minute / 2, upTo: time.Minute},
+		{name: "zero delays", base: 0, max: 0, attempt: 3, min: 0, upTo: 1},
+		{name: "negative delays", base: -time.Second, max: -time.Second, attempt: 1, min: 0, upTo: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				delay := jitteredBackoff(tt.base, tt.max, tt.attempt)
+				if delay < tt.min || delay > tt.upTo {
+					t.Fatalf("delay %v is outside [%v, %v]", delay, tt.min, tt.upTo)
+				}
+			}
+		})
+	}
+}
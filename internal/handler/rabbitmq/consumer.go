@@ -2,30 +2,52 @@ package rabbitmq
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"github.com/Verce11o/yata-notifications/config"
 	"github.com/Verce11o/yata-notifications/internal/domain"
+	"github.com/Verce11o/yata-notifications/internal/metrics/prom"
 	"github.com/Verce11o/yata-notifications/internal/service"
 	amqp "github.com/rabbitmq/amqp091-go"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type NotificationConsumer struct {
-	AmqpConn *amqp.Connection
-	log      *zap.SugaredLogger
-	tracer   trace.Tracer
-	service  service.Notifications
+	conn    *ConnectionManager
+	log     *zap.SugaredLogger
+	tracer  trace.Tracer
+	service service.Notifications
+	cfg     config.RabbitMQ
+	pool    atomic.Pointer[WorkerPool]
+
+	drain     chan struct{}
+	drainOnce sync.Once
+	stopped   chan struct{}
 }
 
-func NewNotificationConsumer(amqpConn *amqp.Connection, log *zap.SugaredLogger, trace trace.Tracer, service service.Notifications) *NotificationConsumer {
-	return &NotificationConsumer{AmqpConn: amqpConn, log: log, tracer: trace, service: service}
+func NewNotificationConsumer(conn *ConnectionManager, log *zap.SugaredLogger, trace trace.Tracer, service service.Notifications, cfg config.RabbitMQ) *NotificationConsumer {
+	return &NotificationConsumer{
+		conn:    conn,
+		log:     log,
+		tracer:  trace,
+		service: service,
+		cfg:     cfg,
+		drain:   make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
 }
 
-func (c *NotificationConsumer) createChannel(exchangeName, queueName, bindingKey string) *amqp.Channel {
-	ch, err := c.AmqpConn.Channel()
+func (c *NotificationConsumer) createChannel(ctx context.Context, exchangeName, queueName, bindingKey string) (*amqp.Channel, error) {
+	ch, err := c.conn.Channel(ctx)
 
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	// think about changing its kind
@@ -40,7 +62,8 @@ func (c *NotificationConsumer) createChannel(exchangeName, queueName, bindingKey
 	)
 
 	if err != nil {
-		panic(err)
+		ch.Close()
+		return nil, err
 	}
 
 	queue, err := ch.QueueDeclare(
@@ -53,7 +76,8 @@ func (c *NotificationConsumer) createChannel(exchangeName, queueName, bindingKey
 	)
 
 	if err != nil {
-		panic(err)
+		ch.Close()
+		return nil, err
 	}
 
 	err = ch.QueueBind(
@@ -65,20 +89,91 @@ func (c *NotificationConsumer) createChannel(exchangeName, queueName, bindingKey
 	)
 
 	if err != nil {
-		panic(err)
+		ch.Close()
+		return nil, err
+	}
+
+	if err := c.declareRetryTopology(ch, exchangeName, queueName, bindingKey); err != nil {
+		ch.Close()
+		return nil, err
+	}
+
+	if err := ch.Qos(c.cfg.Prefetch, 0, false); err != nil {
+		ch.Close()
+		return nil, err
+	}
+
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return nil, err
 	}
 
-	return ch
+	return ch, nil
 
 }
 
-func (c *NotificationConsumer) StartConsumer(queueName, consumerTag, exchangeName, bindingKey string) error {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+func (c *NotificationConsumer) StartConsumer(ctx context.Context, queueName, consumerTag, exchangeName, bindingKey string) error {
+	defer close(c.stopped)
+
+	setupCtx, cancelSetup := context.WithCancel(ctx)
+	defer cancelSetup()
+
+	go func() {
+		select {
+		case <-c.drain:
+			cancelSetup()
+		case <-setupCtx.Done():
+		}
+	}()
+
+	attempt := 0
+
+	for {
+		started, err := c.consume(ctx, setupCtx, queueName, consumerTag, exchangeName, bindingKey)
+
+		if setupCtx.Err() != nil {
+			return nil
+		}
 
-	ch := c.createChannel(exchangeName, queueName, bindingKey)
+		if started {
+			attempt = 0
+		}
+
+		delay := jitteredBackoff(c.cfg.ReconnectBaseDelay, c.cfg.ReconnectMaxDelay, attempt)
+		attempt++
+		c.log.Errorf("consumer stopped, resuming in %s: %v", delay, err)
+
+		select {
+		case <-setupCtx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (c *NotificationConsumer) Shutdown(ctx context.Context) error {
+	c.drainOnce.Do(func() {
+		close(c.drain)
+	})
+
+	select {
+	case <-c.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *NotificationConsumer) consume(ctx, setupCtx context.Context, queueName, consumerTag, exchangeName, bindingKey string) (bool, error) {
+	ch, err := c.createChannel(setupCtx, exchangeName, queueName, bindingKey)
+
+	if err != nil {
+		return false, err
+	}
 	defer ch.Close()
 
+	closed := ch.NotifyClose(make(chan *amqp.Error, 1))
+
 	deliveries, err := ch.Consume(
 		queueName,
 		consumerTag,
@@ -90,63 +185,122 @@ func (c *NotificationConsumer) StartConsumer(queueName, consumerTag, exchangeNam
 	)
 
 	if err != nil {
-		panic(err)
+		return false, err
 	}
 
-	for i := 0; i < 5; i++ {
-		i := i
-		go c.worker(ctx, i, deliveries)
+	poolCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pool := NewWorkerPool(c.log, c.cfg.Workers,
+		func(ctx context.Context, message amqp.Delivery) error {
+			return c.handleDelivery(ctx, ch, queueName, message)
+		},
+		func(ctx context.Context, message amqp.Delivery, recovered any) {
+			c.log.Errorf("recovered panic while handling message: %v", recovered)
+			c.retryOrDeadLetter(ctx, ch, queueName, message, fmt.Errorf("panic: %v", recovered), true)
+		},
+	)
+	c.pool.Store(pool)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pool.Run(poolCtx, deliveries)
+	}()
+
+	select {
+	case <-ctx.Done():
+		err = ctx.Err()
+	case chanErr := <-closed:
+		c.log.Infof("Notify close: %v", chanErr)
+		err = chanErr
+		if chanErr == nil {
+			err = amqp.ErrClosed
+		}
+	case <-c.drain:
+		c.log.Info("draining consumer")
+
+		if err := ch.Cancel(consumerTag, false); err != nil {
+			c.log.Errorf("cannot cancel consumer: %v", err)
+		}
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
 	}
-	chanErr := <-ch.NotifyClose(make(chan *amqp.Error))
-	c.log.Infof("Notify close: %v", chanErr)
 
-	return chanErr
+	cancel()
+	<-done
 
+	return true, err
 }
 
-func (c *NotificationConsumer) worker(ctx context.Context, index int, messages <-chan amqp.Delivery) {
-	for message := range messages {
-		c.log.Infof("Worker #%d: %v", index, string(message.Body))
+func (c *NotificationConsumer) Health() PoolHealth {
+	pool := c.pool.Load()
+	if pool == nil {
+		return PoolHealth{}
+	}
+	return pool.Health()
+}
 
-		var request domain.IncomingNewNotification
+func (c *NotificationConsumer) ConnectionState() ConnectionState {
+	return c.conn.State()
+}
 
-		err := json.Unmarshal(message.Body, &request)
+func (c *NotificationConsumer) handleDelivery(ctx context.Context, ch *amqp.Channel, queueName string, message amqp.Delivery) error {
+	c.log.Infof("Received message: %v", string(message.Body))
 
-		if err != nil {
-			c.log.Errorf("failed to unmarshal request: %v", err)
-			if err := message.Nack(false, false); err != nil {
-				c.log.Errorf("cannot nack message: %v", err)
-			}
-			return
-		}
+	start := time.Now()
+	defer func() {
+		prom.ConsumerProcessingDuration.Observe(time.Since(start).Seconds())
+	}()
 
-		subscribers, err := c.service.GetUserSubscribers(ctx, request.SenderID.String())
-		c.log.Debugf("%#v", subscribers)
+	if !message.Timestamp.IsZero() {
+		prom.ConsumerQueueLag.Observe(start.Sub(message.Timestamp).Seconds())
+	}
 
-		if err != nil {
-			c.log.Errorf("failed to get user subscribers: %v", err)
-			if err := message.Nack(false, false); err != nil {
-				c.log.Errorf("cannot nack message: %v", err)
-			}
-			return
-		}
+	var request domain.IncomingNewNotification
 
-		err = c.service.BatchAddNotification(ctx, subscribers, request)
+	err := json.Unmarshal(message.Body, &request)
 
-		if err != nil {
-			c.log.Errorf("failed to add notification: %v", err)
-			if err := message.Nack(false, false); err != nil {
-				c.log.Errorf("cannot nack message: %v", err)
-			}
-			return
-		}
+	if err != nil {
+		c.log.Errorf("failed to unmarshal request: %v", err)
+		c.retryOrDeadLetter(ctx, ch, queueName, message, err, false)
+		return nil
+	}
 
-		err = message.Ack(false)
+	if request.EventID == "" {
+		request.EventID = message.MessageId
+	}
+	request.DedupKey = dedupKey(request.EventID, message)
 
-		if err != nil {
-			c.log.Errorf("failed to acknowledge delivery: %v", err)
-		}
+	err = c.service.NotifySubscribers(ctx, request)
 
+	if err != nil {
+		c.log.Errorf("failed to add notification: %v", err)
+		c.retryOrDeadLetter(ctx, ch, queueName, message, err, true)
+		return nil
 	}
-	c.log.Info("Channel closed")
+
+	err = message.Ack(false)
+
+	if err != nil {
+		c.log.Errorf("failed to acknowledge delivery: %v", err)
+		return err
+	}
+
+	prom.ConsumerMessages.WithLabelValues("ack").Inc()
+
+	return nil
+}
+
+func dedupKey(eventID string, message amqp.Delivery) string {
+	if eventID != "" {
+		return eventID
+	}
+
+	sum := sha256.Sum256(message.Body)
+	return "body:" + hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,157 @@
+package rabbitmq
+
+import (
+	"context"
+	"fmt"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultWorkers      = 5
+	workerRestartDelay  = time.Second
+	maxWorkerRestartLag = 30 * time.Second
+	workerHealthyUptime = time.Minute
+)
+
+// HandlerFunc returning an error makes the pool restart the worker.
+type HandlerFunc func(ctx context.Context, message amqp.Delivery) error
+
+type PanicHandlerFunc func(ctx context.Context, message amqp.Delivery, recovered any)
+
+type PoolHealth struct {
+	Size      int   `json:"size"`
+	Alive     int   `json:"alive"`
+	Restarts  int64 `json:"restarts"`
+	Panics    int64 `json:"panics"`
+	Processed int64 `json:"processed"`
+}
+
+func (h PoolHealth) Healthy() bool {
+	return h.Size > 0 && h.Alive == h.Size
+}
+
+type WorkerPool struct {
+	log     *zap.SugaredLogger
+	size    int
+	handle  HandlerFunc
+	onPanic PanicHandlerFunc
+
+	restartDelay    time.Duration
+	maxRestartDelay time.Duration
+	healthyUptime   time.Duration
+
+	alive     atomic.Int32
+	restarts  atomic.Int64
+	panics    atomic.Int64
+	processed atomic.Int64
+}
+
+func NewWorkerPool(log *zap.SugaredLogger, size int, handle HandlerFunc, onPanic PanicHandlerFunc) *WorkerPool {
+	if size <= 0 {
+		size = defaultWorkers
+	}
+	return &WorkerPool{
+		log:             log,
+		size:            size,
+		handle:          handle,
+		onPanic:         onPanic,
+		restartDelay:    workerRestartDelay,
+		maxRestartDelay: maxWorkerRestartLag,
+		healthyUptime:   workerHealthyUptime,
+	}
+}
+
+func (p *WorkerPool) Run(ctx context.Context, deliveries <-chan amqp.Delivery) {
+	var wg sync.WaitGroup
+
+	for i := 0; i < p.size; i++ {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			p.supervise(ctx, index, deliveries)
+		}(i)
+	}
+
+	wg.Wait()
+}
+
+func (p *WorkerPool) Health() PoolHealth {
+	return PoolHealth{
+		Size:      p.size,
+		Alive:     int(p.alive.Load()),
+		Restarts:  p.restarts.Load(),
+		Panics:    p.panics.Load(),
+		Processed: p.processed.Load(),
+	}
+}
+
+func (p *WorkerPool) supervise(ctx context.Context, index int, deliveries <-chan amqp.Delivery) {
+	delay := p.restartDelay
+
+	for {
+		started := time.Now()
+
+		p.alive.Add(1)
+		err := p.work(ctx, index, deliveries)
+		p.alive.Add(-1)
+
+		if err == nil {
+			return
+		}
+
+		if time.Since(started) >= p.healthyUptime {
+			delay = p.restartDelay
+		}
+
+		p.restarts.Add(1)
+		p.log.Errorf("worker #%d failed, restarting in %s: %v", index, delay, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > p.maxRestartDelay {
+			delay = p.maxRestartDelay
+		}
+	}
+}
+
+func (p *WorkerPool) work(ctx context.Context, index int, deliveries <-chan amqp.Delivery) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case message, ok := <-deliveries:
+			if !ok {
+				p.log.Infof("worker #%d: deliveries closed", index)
+				return nil
+			}
+
+			if err := p.process(ctx, message); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (p *WorkerPool) process(ctx context.Context, message amqp.Delivery) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			p.panics.Add(1)
+			p.onPanic(ctx, message, recovered)
+			err = fmt.Errorf("panic while handling delivery %d: %v", message.DeliveryTag, recovered)
+		}
+	}()
+
+	err = p.handle(ctx, message)
+	p.processed.Add(1)
+
+	return err
+}
@@ -0,0 +1,158 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestPool(size int, handle HandlerFunc, onPanic PanicHandlerFunc) *WorkerPool {
+	pool := NewWorkerPool(zap.NewNop().Sugar(), size, handle, onPanic)
+	pool.restartDelay = time.Millisecond
+	pool.maxRestartDelay = time.Millisecond
+	return pool
+}
+
+func feed(tags ...uint64) <-chan amqp.Delivery {
+	deliveries := make(chan amqp.Delivery, len(tags))
+	for _, tag := range tags {
+		deliveries <- amqp.Delivery{DeliveryTag: tag}
+	}
+	close(deliveries)
+	return deliveries
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWorkerPoolRecoversPanickingHandler(t *testing.T) {
+	var mu sync.Mutex
+	var handled, panicked []uint64
+
+	pool := newTestPool(1,
+		func(ctx context.Context, message amqp.Delivery) error {
+			if message.DeliveryTag == 1 {
+				panic("boom")
+			}
+			mu.Lock()
+			handled = append(handled, message.DeliveryTag)
+			mu.Unlock()
+			return nil
+		},
+		func(ctx context.Context, message amqp.Delivery, recovered any) {
+			mu.Lock()
+			panicked = append(panicked, message.DeliveryTag)
+			mu.Unlock()
+		},
+	)
+
+	pool.Run(context.Background(), feed(1, 2))
+
+	if len(panicked) != 1 || panicked[0] != 1 {
+		t.Fatalf("panic handler got deliveries %v, want [1]", panicked)
+	}
+
+	if len(handled) != 1 || handled[0] != 2 {
+		t.Fatalf("handled deliveries %v, want [2]", handled)
+	}
+
+	health := pool.Health()
+	if health.Panics != 1 || health.Restarts != 1 || health.Processed != 1 {
+		t.Fatalf("health = %+v, want 1 panic, 1 restart and 1 processed delivery", health)
+	}
+}
+
+func TestWorkerPoolRestartsFailedWorker(t *testing.T) {
+	var mu sync.Mutex
+	var handled []uint64
+
+	pool := newTestPool(1,
+		func(ctx context.Context, message amqp.Delivery) error {
+			mu.Lock()
+			handled = append(handled, message.DeliveryTag)
+			mu.Unlock()
+			if message.DeliveryTag == 1 {
+				return errors.New("channel closed")
+			}
+			return nil
+		},
+		func(ctx context.Context, message amqp.Delivery, recovered any) {},
+	)
+
+	pool.Run(context.Background(), feed(1, 2, 3))
+
+	if len(handled) != 3 {
+		t.Fatalf("handled deliveries %v, want all 3 after the restart", handled)
+	}
+
+	health := pool.Health()
+	if health.Restarts != 1 || health.Processed != 3 {
+		t.Fatalf("health = %+v, want 1 restart and 3 processed deliveries", health)
+	}
+
+	if health.Alive != 0 {
+		t.Fatalf("%d workers alive after deliveries closed, want 0", health.Alive)
+	}
+}
+
+func TestWorkerPoolHealthReportsDeadWorkers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pool := newTestPool(2,
+		func(ctx context.Context, message amqp.Delivery) error {
+			return errors.New("channel closed")
+		},
+		func(ctx context.Context, message amqp.Delivery, recovered any) {},
+	)
+	pool.restartDelay = time.Hour
+	pool.maxRestartDelay = time.Hour
+
+	deliveries := make(chan amqp.Delivery)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		pool.Run(ctx, deliveries)
+	}()
+
+	waitFor(t, "all workers to start", func() bool {
+		return pool.Health().Healthy()
+	})
+
+	deliveries <- amqp.Delivery{DeliveryTag: 1}
+
+	waitFor(t, "the failed worker to be restarted", func() bool {
+		return pool.Health().Restarts == 1
+	})
+
+	health := pool.Health()
+	if health.Size != 2 || health.Alive != 1 || health.Healthy() {
+		t.Fatalf("health = %+v, want 1 of 2 workers alive and unhealthy", health)
+	}
+
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("pool did not stop after ctx was cancelled")
+	}
+
+	if alive := pool.Health().Alive; alive != 0 {
+		t.Fatalf("%d workers alive after stop, want 0", alive)
+	}
+}
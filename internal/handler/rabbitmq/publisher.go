@@ -0,0 +1,123 @@
+package rabbitmq
+
+import (
+	"context"
+	"github.com/Verce11o/yata-notifications/config"
+	"github.com/Verce11o/yata-notifications/internal/domain"
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+	"sync"
+)
+
+type OutboxPublisher struct {
+	conn *ConnectionManager
+	log  *zap.SugaredLogger
+	cfg  config.Outbox
+
+	mu sync.Mutex
+	ch *amqp.Channel
+}
+
+func NewOutboxPublisher(conn *ConnectionManager, log *zap.SugaredLogger, cfg config.Outbox) *OutboxPublisher {
+	return &OutboxPublisher{conn: conn, log: log, cfg: cfg}
+}
+
+func (p *OutboxPublisher) Publish(ctx context.Context, messages []domain.OutboxMessage) ([]uuid.UUID, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ch, err := p.channel(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	confirms := make([]*amqp.DeferredConfirmation, 0, len(messages))
+
+	for _, message := range messages {
+		confirm, err := ch.PublishWithDeferredConfirmWithContext(ctx, p.cfg.Exchange, message.EventType, false, false, amqp.Publishing{
+			MessageId:    message.ID.String(),
+			Type:         message.EventType,
+			ContentType:  "application/json",
+			DeliveryMode: amqp.Persistent,
+			Timestamp:    message.CreatedAt,
+			Body:         message.Payload,
+		})
+
+		if err != nil {
+			p.resetChannel()
+			return p.confirmed(ctx, messages, confirms), err
+		}
+
+		confirms = append(confirms, confirm)
+	}
+
+	return p.confirmed(ctx, messages, confirms), nil
+}
+
+func (p *OutboxPublisher) confirmed(ctx context.Context, messages []domain.OutboxMessage, confirms []*amqp.DeferredConfirmation) []uuid.UUID {
+	result := make([]uuid.UUID, 0, len(confirms))
+
+	for i, confirm := range confirms {
+		acked, err := confirm.WaitContext(ctx)
+		if err != nil {
+			p.log.Errorf("cannot wait for publisher confirm: %v", err.Error())
+			break
+		}
+
+		if acked {
+			result = append(result, messages[i].ID)
+		}
+	}
+
+	return result
+}
+
+func (p *OutboxPublisher) channel(ctx context.Context) (*amqp.Channel, error) {
+	if p.ch != nil && !p.ch.IsClosed() {
+		return p.ch, nil
+	}
+
+	ch, err := p.conn.Channel(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ch.ExchangeDeclare(
+		p.cfg.Exchange,
+		"topic",
+		true,
+		false,
+		false,
+		false,
+		nil,
+	)
+
+	if err != nil {
+		ch.Close()
+		return nil, err
+	}
+
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return nil, err
+	}
+
+	p.ch = ch
+
+	return ch, nil
+}
+
+func (p *OutboxPublisher) resetChannel() {
+	if p.ch != nil {
+		p.ch.Close()
+		p.ch = nil
+	}
+}
+
+func (p *OutboxPublisher) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.resetChannel()
+}
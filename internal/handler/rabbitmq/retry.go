@@ -0,0 +1,210 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Verce11o/yata-notifications/internal/metrics/prom"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"time"
+)
+
+const (
+	retryCountHeader = "x-retry-count"
+	lastErrorHeader  = "x-last-error"
+)
+
+func (c *NotificationConsumer) declareRetryTopology(ch *amqp.Channel, exchangeName, queueName, bindingKey string) error {
+	for attempt := 1; attempt <= c.cfg.MaxRetries; attempt++ {
+		_, err := ch.QueueDeclare(
+			retryQueueName(queueName, attempt),
+			true,
+			false,
+			false,
+			false,
+			amqp.Table{
+				"x-message-ttl":             c.retryDelay(attempt).Milliseconds(),
+				"x-dead-letter-exchange":    exchangeName,
+				"x-dead-letter-routing-key": bindingKey,
+			},
+		)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	err := ch.ExchangeDeclare(
+		c.cfg.DeadLetterExchange,
+		"direct",
+		true,
+		false,
+		false,
+		false,
+		nil,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	dlq, err := ch.QueueDeclare(
+		c.cfg.DeadLetterQueue,
+		true,
+		false,
+		false,
+		false,
+		nil,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return ch.QueueBind(
+		dlq.Name,
+		queueName,
+		c.cfg.DeadLetterExchange,
+		false,
+		nil,
+	)
+}
+
+func (c *NotificationConsumer) retryOrDeadLetter(ctx context.Context, ch *amqp.Channel, queueName string, message amqp.Delivery, cause error, retryable bool) {
+	attempt := retryCount(message) + 1
+
+	headers := amqp.Table{}
+	for key, value := range message.Headers {
+		headers[key] = value
+	}
+	headers[retryCountHeader] = int32(attempt)
+	headers[lastErrorHeader] = cause.Error()
+
+	exchange, routingKey, result := "", retryQueueName(queueName, attempt), "retry"
+	if !retryable || attempt > c.cfg.MaxRetries {
+		exchange, routingKey, result = c.cfg.DeadLetterExchange, queueName, "dead_letter"
+		c.log.Warnf("moving message to dead-letter queue after %d attempts: %v", attempt, cause)
+	}
+
+	err := publishConfirmed(ctx, ch, exchange, routingKey, amqp.Publishing{
+		Headers:      headers,
+		ContentType:  message.ContentType,
+		DeliveryMode: amqp.Persistent,
+		MessageId:    message.MessageId,
+		Timestamp:    message.Timestamp,
+		Body:         message.Body,
+	})
+
+	if err != nil {
+		c.log.Errorf("cannot publish message for retry: %v", err)
+		prom.ConsumerMessages.WithLabelValues("requeue").Inc()
+		if err := message.Nack(false, true); err != nil {
+			c.log.Errorf("cannot nack message: %v", err)
+		}
+		return
+	}
+
+	prom.ConsumerMessages.WithLabelValues(result).Inc()
+
+	if err := message.Ack(false); err != nil {
+		c.log.Errorf("failed to acknowledge delivery: %v", err)
+	}
+}
+
+func (c *NotificationConsumer) RedriveDeadLetters(ctx context.Context, limit int) (int, error) {
+	ch, err := c.conn.Channel(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer ch.Close()
+
+	if err := ch.Confirm(false); err != nil {
+		return 0, err
+	}
+
+	redriven := 0
+
+	for redriven < limit {
+		message, ok, err := ch.Get(c.cfg.DeadLetterQueue, false)
+		if err != nil {
+			return redriven, err
+		}
+
+		if !ok {
+			break
+		}
+
+		headers := amqp.Table{}
+		for key, value := range message.Headers {
+			headers[key] = value
+		}
+		delete(headers, retryCountHeader)
+		delete(headers, lastErrorHeader)
+
+		err = publishConfirmed(ctx, ch, c.cfg.ExchangeName, c.cfg.BindingKey, amqp.Publishing{
+			Headers:      headers,
+			ContentType:  message.ContentType,
+			DeliveryMode: amqp.Persistent,
+			MessageId:    message.MessageId,
+			Timestamp:    message.Timestamp,
+			Body:         message.Body,
+		})
+
+		if err != nil {
+			if err := message.Nack(false, true); err != nil {
+				c.log.Errorf("cannot nack message: %v", err)
+			}
+			return redriven, err
+		}
+
+		if err := message.Ack(false); err != nil {
+			return redriven, err
+		}
+
+		redriven++
+	}
+
+	return redriven, nil
+}
+
+func publishConfirmed(ctx context.Context, ch *amqp.Channel, exchange, routingKey string, publishing amqp.Publishing) error {
+	confirm, err := ch.PublishWithDeferredConfirmWithContext(ctx, exchange, routingKey, false, false, publishing)
+	if err != nil {
+		return err
+	}
+
+	acked, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !acked {
+		return errors.New("message was nacked by the broker")
+	}
+
+	return nil
+}
+
+func (c *NotificationConsumer) retryDelay(attempt int) time.Duration {
+	delay := c.cfg.RetryBaseDelay << (attempt - 1)
+	if c.cfg.RetryMaxDelay > 0 && (delay > c.cfg.RetryMaxDelay || delay <= 0) {
+		return c.cfg.RetryMaxDelay
+	}
+	return delay
+}
+
+func retryQueueName(queueName string, attempt int) string {
+	return fmt.Sprintf("%s.retry.%d", queueName, attempt)
+}
+
+func retryCount(message amqp.Delivery) int {
+	switch count := message.Headers[retryCountHeader].(type) {
+	case int32:
+		return int(count)
+	case int64:
+		return int(count)
+	case int:
+		return count
+	}
+	return 0
+}
@@ -0,0 +1,182 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"go.uber.org/zap"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultInterval = 10 * time.Second
+	checkTimeout    = 3 * time.Second
+)
+
+type Check func(ctx context.Context) error
+
+type dependency struct {
+	name  string
+	check Check
+}
+
+type Checker struct {
+	log      *zap.SugaredLogger
+	server   *grpchealth.Server
+	services []string
+	interval time.Duration
+
+	dependencies []dependency
+
+	mu           sync.RWMutex
+	results      map[string]error
+	checked      bool
+	shuttingDown atomic.Bool
+}
+
+func NewChecker(log *zap.SugaredLogger, interval time.Duration, services ...string) *Checker {
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	server := grpchealth.NewServer()
+	services = append([]string{""}, services...)
+
+	for _, service := range services {
+		server.SetServingStatus(service, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+
+	return &Checker{
+		log:      log,
+		server:   server,
+		services: services,
+		interval: interval,
+		results:  make(map[string]error),
+	}
+}
+
+func (c *Checker) Add(name string, check Check) {
+	c.dependencies = append(c.dependencies, dependency{name: name, check: check})
+	c.server.SetServingStatus(name, healthpb.HealthCheckResponse_NOT_SERVING)
+}
+
+func (c *Checker) Server() healthpb.HealthServer {
+	return c.server
+}
+
+func (c *Checker) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		c.checkAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Checker) Shutdown() {
+	c.shuttingDown.Store(true)
+	c.server.Shutdown()
+}
+
+func (c *Checker) Ready() bool {
+	if c.shuttingDown.Load() {
+		return false
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if !c.checked {
+		return false
+	}
+
+	for _, err := range c.results {
+		if err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *Checker) checkAll(ctx context.Context) {
+	results := make(map[string]error, len(c.dependencies))
+
+	for _, dependency := range c.dependencies {
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		err := dependency.check(checkCtx)
+		cancel()
+
+		results[dependency.name] = err
+
+		status := healthpb.HealthCheckResponse_SERVING
+		if err != nil {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+			c.log.Errorf("health check %s failed: %v", dependency.name, err.Error())
+		}
+		c.server.SetServingStatus(dependency.name, status)
+	}
+
+	c.mu.Lock()
+	c.results = results
+	c.checked = true
+	c.mu.Unlock()
+
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if c.Ready() {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
+
+	for _, service := range c.services {
+		c.server.SetServingStatus(service, status)
+	}
+}
+
+func (c *Checker) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", c.Liveness)
+	mux.HandleFunc("/readyz", c.Readiness)
+}
+
+func (c *Checker) Liveness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+func (c *Checker) Readiness(w http.ResponseWriter, r *http.Request) {
+	c.mu.RLock()
+	checks := make(map[string]string, len(c.results))
+	for name, err := range c.results {
+		checks[name] = "ok"
+		if err != nil {
+			checks[name] = err.Error()
+		}
+	}
+	c.mu.RUnlock()
+
+	status := "ready"
+	if c.shuttingDown.Load() {
+		status = "shutting down"
+	}
+
+	ready := c.Ready()
+	if !ready && status == "ready" {
+		status = "not ready"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string]any{"status": status, "checks": checks})
+}
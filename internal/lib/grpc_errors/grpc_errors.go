@@ -15,6 +15,12 @@ var (
 	ErrInvalidCursor    = errors.New("invalid pagination cursor")
 	ErrSubAlreadyExists = errors.New("already subscribed")
 	ErrInvalidUser      = errors.New("invalid user")
+
+	ErrInvalidNotificationType  = errors.New("invalid notification type")
+	ErrInvalidQuietHours        = errors.New("invalid quiet hours")
+	ErrInvalidDigest            = errors.New("invalid digest subscription")
+	ErrBatchTooLarge            = errors.New("too many ids in batch")
+	ErrInvalidSubscriptionLevel = errors.New("invalid subscription level")
 )
 
 func ParseGRPCErrStatusCode(err error) codes.Code {
@@ -39,6 +45,16 @@ func ParseGRPCErrStatusCode(err error) codes.Code {
 		return codes.InvalidArgument
 	case errors.Is(err, ErrInvalidCursor):
 		return codes.InvalidArgument
+	case errors.Is(err, ErrInvalidNotificationType):
+		return codes.InvalidArgument
+	case errors.Is(err, ErrInvalidQuietHours):
+		return codes.InvalidArgument
+	case errors.Is(err, ErrInvalidDigest):
+		return codes.InvalidArgument
+	case errors.Is(err, ErrBatchTooLarge):
+		return codes.InvalidArgument
+	case errors.Is(err, ErrInvalidSubscriptionLevel):
+		return codes.InvalidArgument
 	}
 	return codes.Internal
 }
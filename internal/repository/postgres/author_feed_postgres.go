@@ -0,0 +1,210 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/Verce11o/yata-notifications/internal/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/types"
+	"github.com/lib/pq"
+	"time"
+)
+
+const feedInboxSelect = `SELECT f.feed_event_id AS notification_id, s.user_id AS to_user_id, f.author_id AS from_user_id,
+			f.type, f.entity_kind, f.entity_id, f.metadata, f.group_key, r.feed_event_id IS NOT NULL AS read, f.created_at
+		FROM subscribers s
+		JOIN author_feed f ON f.author_id = s.to_user_id AND f.created_at >= s.created_at
+		LEFT JOIN author_feed_reads r ON r.user_id = s.user_id AND r.feed_event_id = f.feed_event_id
+		LEFT JOIN notification_preferences p ON p.user_id = s.user_id
+		WHERE s.user_id = $1
+			AND (s.level = 'all' OR (s.level = 'types' AND f.type = ANY(s.types)))
+			AND (p.user_id IS NULL OR NOT (f.author_id = ANY(p.muted_senders) OR f.type = ANY(p.disabled_types)))
+			AND (p.muted_until IS NULL OR p.muted_until <= f.created_at)`
+
+func inboxCTE(personal string, feed string, tail string) string {
+	return `inbox AS (
+			(SELECT notification_id, to_user_id, from_user_id, type, entity_kind, entity_id, metadata, group_key, read, created_at
+			FROM notifications
+			WHERE to_user_id = $1 AND (` + personal + `)
+			` + tail + `)
+			UNION ALL
+			(` + feedInboxSelect + `
+				AND (` + feed + `)
+			` + tail + `)
+		)`
+}
+
+const feedInboxCTE = `feed_inbox AS (
+		` + feedInboxSelect + `
+	)`
+
+func (n *NotificationsPostgres) AddAuthorFeedEvent(ctx context.Context, input domain.IncomingNewNotification) (domain.Notification, bool, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.AddAuthorFeedEvent")
+	defer span.End()
+	defer observeQuery("AddAuthorFeedEvent")()
+
+	metadata := input.Metadata
+	if len(metadata) == 0 {
+		metadata = types.JSONText("{}")
+	}
+
+	q := `WITH inserted AS (
+			INSERT INTO author_feed (author_id, type, entity_kind, entity_id, metadata, group_key, event_id)
+			VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''))
+			ON CONFLICT (event_id) DO NOTHING
+			RETURNING feed_event_id, author_id, type, entity_kind, entity_id, metadata, created_at
+		), outbox AS (
+			INSERT INTO notification_outbox (event_type, payload)
+			SELECT $8, to_jsonb(inserted) FROM inserted
+		), groups AS (
+			INSERT INTO author_feed_groups (author_id, group_key, first_created_at, first_feed_event_id)
+			SELECT author_id, COALESCE(NULLIF($6, ''), feed_event_id::text), created_at, feed_event_id FROM inserted
+			ON CONFLICT (author_id, group_key) DO NOTHING
+		)
+		SELECT feed_event_id AS notification_id, author_id AS from_user_id, type, entity_kind, entity_id, metadata, created_at FROM inserted`
+
+	var notification domain.Notification
+
+	err := n.db.QueryRowxContext(ctx, q, input.SenderID, input.Type, input.Entity.Kind, input.Entity.ID, metadata, input.GroupKey, input.EventID, domain.OutboxAuthorFeedCreated).StructScan(&notification)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Notification{}, false, nil
+	}
+
+	if err != nil {
+		return domain.Notification{}, false, err
+	}
+
+	return notification, true, nil
+}
+
+func (n *NotificationsPostgres) GetFeedAuthors(ctx context.Context, userID string, minFollowers int64) ([]domain.Subscriber, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.GetFeedAuthors")
+	defer span.End()
+	defer observeQuery("GetFeedAuthors")()
+
+	q := `SELECT s.id, s.user_id, s.to_user_id, s.created_at, s.updated_at, s.level, s.types
+		FROM subscribers s
+		JOIN subscription_counts c ON c.user_id = s.to_user_id
+		WHERE s.user_id = $1 AND s.level <> 'none' AND c.followers >= $2`
+
+	var result []domain.Subscriber
+
+	err := sqlx.SelectContext(ctx, n.db, &result, q, userID, minFollowers)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (n *NotificationsPostgres) MarkFeedEventsAsRead(ctx context.Context, userID string, notificationID string) (int64, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.MarkFeedEventsAsRead")
+	defer span.End()
+	defer observeQuery("MarkFeedEventsAsRead")()
+
+	q := `WITH ` + feedInboxCTE + `
+		INSERT INTO author_feed_reads (user_id, feed_event_id)
+		SELECT $1, notification_id FROM feed_inbox
+		WHERE read = FALSE AND (notification_id = $2 OR group_key = COALESCE(
+			(SELECT group_key FROM notifications WHERE to_user_id = $1 AND notification_id = $2),
+			(SELECT group_key FROM author_feed WHERE feed_event_id = $2)
+		))
+		ON CONFLICT DO NOTHING`
+
+	res, err := n.db.ExecContext(ctx, q, userID, notificationID)
+
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rows, nil
+}
+
+func (n *NotificationsPostgres) ReadAllFeedEvents(ctx context.Context, userID string) (int64, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.ReadAllFeedEvents")
+	defer span.End()
+	defer observeQuery("ReadAllFeedEvents")()
+
+	q := `WITH ` + feedInboxCTE + `
+		INSERT INTO author_feed_reads (user_id, feed_event_id)
+		SELECT $1, notification_id FROM feed_inbox WHERE read = FALSE
+		ON CONFLICT DO NOTHING`
+
+	res, err := n.db.ExecContext(ctx, q, userID)
+
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rows, nil
+}
+
+func (n *NotificationsPostgres) CountUnreadFeedEvents(ctx context.Context, userIDs []string, since time.Time) (map[string]int64, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.CountUnreadFeedEvents")
+	defer span.End()
+	defer observeQuery("CountUnreadFeedEvents")()
+
+	q := `SELECT s.user_id, COUNT(*) AS count
+		FROM subscribers s
+		JOIN author_feed f ON f.author_id = s.to_user_id AND f.created_at >= s.created_at AND f.created_at >= $2
+		LEFT JOIN author_feed_reads r ON r.user_id = s.user_id AND r.feed_event_id = f.feed_event_id
+		LEFT JOIN notification_preferences p ON p.user_id = s.user_id
+		WHERE s.user_id = ANY($1::uuid[]) AND r.feed_event_id IS NULL
+			AND (s.level = 'all' OR (s.level = 'types' AND f.type = ANY(s.types)))
+			AND (p.user_id IS NULL OR NOT (f.author_id = ANY(p.muted_senders) OR f.type = ANY(p.disabled_types)))
+			AND (p.muted_until IS NULL OR p.muted_until <= f.created_at)
+		GROUP BY s.user_id`
+
+	rows, err := n.db.QueryxContext(ctx, q, pq.StringArray(userIDs), since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string]int64, len(userIDs))
+
+	for rows.Next() {
+		var userID string
+		var count int64
+
+		if err := rows.Scan(&userID, &count); err != nil {
+			return nil, err
+		}
+
+		result[userID] = count
+	}
+
+	return result, rows.Err()
+}
+
+func (n *NotificationsPostgres) GetLatestFeedEventAt(ctx context.Context, userID string) (time.Time, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.GetLatestFeedEventAt")
+	defer span.End()
+	defer observeQuery("GetLatestFeedEventAt")()
+
+	q := `SELECT COALESCE(MAX(f.created_at), 'epoch'::timestamptz)
+		FROM subscribers s
+		JOIN author_feed f ON f.author_id = s.to_user_id
+		WHERE s.user_id = $1`
+
+	var latest time.Time
+
+	err := n.db.QueryRowxContext(ctx, q, userID).Scan(&latest)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return latest, nil
+}
@@ -0,0 +1,113 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"github.com/Verce11o/yata-notifications/internal/domain"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+func (n *NotificationsPostgres) SubscribeToDigest(ctx context.Context, subscription domain.DigestSubscription) error {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.SubscribeToDigest")
+	defer span.End()
+	defer observeQuery("SubscribeToDigest")()
+
+	q := `INSERT INTO digest_subscriptions (user_id, email, frequency) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET email = EXCLUDED.email, frequency = EXCLUDED.frequency`
+
+	_, err := n.db.ExecContext(ctx, q, subscription.UserID, subscription.Email, subscription.Frequency)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (n *NotificationsPostgres) UnsubscribeFromDigest(ctx context.Context, userID string) error {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.UnsubscribeFromDigest")
+	defer span.End()
+	defer observeQuery("UnsubscribeFromDigest")()
+
+	q := "DELETE FROM digest_subscriptions WHERE user_id = $1"
+
+	res, err := n.db.ExecContext(ctx, q, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, _ := res.RowsAffected()
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (n *NotificationsPostgres) ClaimDueDigests(ctx context.Context, now time.Time, limit int) ([]domain.DigestSubscription, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.ClaimDueDigests")
+	defer span.End()
+	defer observeQuery("ClaimDueDigests")()
+
+	q := `WITH due AS (
+			SELECT user_id, last_sent_at FROM digest_subscriptions
+			WHERE (frequency = 'daily' AND last_sent_at <= $1 - INTERVAL '1 day')
+				OR (frequency = 'weekly' AND last_sent_at <= $1 - INTERVAL '7 days')
+			ORDER BY last_sent_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE digest_subscriptions d SET last_sent_at = $1
+		FROM due
+		WHERE d.user_id = due.user_id
+		RETURNING d.user_id, d.email, d.frequency, due.last_sent_at`
+
+	var result []domain.DigestSubscription
+
+	err := sqlx.SelectContext(ctx, n.db, &result, q, now, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (n *NotificationsPostgres) ResetDigestSentAt(ctx context.Context, userID string, lastSentAt time.Time) error {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.ResetDigestSentAt")
+	defer span.End()
+	defer observeQuery("ResetDigestSentAt")()
+
+	q := "UPDATE digest_subscriptions SET last_sent_at = $2 WHERE user_id = $1"
+
+	_, err := n.db.ExecContext(ctx, q, userID, lastSentAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (n *NotificationsPostgres) GetUnreadNotificationsSince(ctx context.Context, userID string, since time.Time, limit int) ([]domain.Notification, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.GetUnreadNotificationsSince")
+	defer span.End()
+	defer observeQuery("GetUnreadNotificationsSince")()
+
+	q := `WITH ` + inboxCTE(
+		`read = FALSE AND created_at > $2`,
+		`r.feed_event_id IS NULL AND f.created_at > $2`,
+		`ORDER BY created_at DESC LIMIT $3`,
+	) + `
+		SELECT notification_id, to_user_id, from_user_id, type, entity_kind, entity_id, metadata, read, created_at
+		FROM inbox
+		ORDER BY created_at DESC
+		LIMIT $3`
+
+	var result []domain.Notification
+
+	err := sqlx.SelectContext(ctx, n.db, &result, q, userID, since, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
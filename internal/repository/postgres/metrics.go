@@ -0,0 +1,13 @@
+package postgres
+
+import (
+	"github.com/Verce11o/yata-notifications/internal/metrics/prom"
+	"time"
+)
+
+func observeQuery(method string) func() {
+	start := time.Now()
+	return func() {
+		prom.PostgresQueryDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	}
+}
@@ -5,9 +5,12 @@ import (
 	"database/sql"
 	"errors"
 	"github.com/Verce11o/yata-notifications/internal/domain"
+	"github.com/Verce11o/yata-notifications/internal/lib/grpc_errors"
 	"github.com/Verce11o/yata-notifications/internal/lib/pagination"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/types"
+	"github.com/lib/pq"
 	"go.opentelemetry.io/otel/trace"
 	"time"
 )
@@ -18,31 +21,56 @@ type NotificationsPostgres struct {
 }
 
 const (
-	paginationLimit = 30
+	paginationLimit   = 30
+	recentActorsLimit = 5
 )
 
 func NewNotificationsPostgres(db *sqlx.DB, tracer trace.Tracer) *NotificationsPostgres {
 	return &NotificationsPostgres{db: db, tracer: tracer}
 }
 
-func (n *NotificationsPostgres) SubscribeToUser(ctx context.Context, userID, toUserID string) error {
+func (n *NotificationsPostgres) SubscribeToUser(ctx context.Context, userID, toUserID string, level domain.SubscriptionLevel, types []string) error {
 	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.SubscribeUser")
 	defer span.End()
-
-	q := "INSERT INTO subscribers(user_id, to_user_id) VALUES ($1, $2)"
-
-	_, err := n.db.ExecContext(ctx, q, userID, toUserID)
+	defer observeQuery("SubscribeUser")()
+
+	q := `WITH inserted AS (
+			INSERT INTO subscribers(user_id, to_user_id, level, types) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (user_id, to_user_id) DO NOTHING
+			RETURNING user_id, to_user_id
+		), counts AS (
+			INSERT INTO subscription_counts (user_id, followers, following)
+			SELECT user_id, followers, following FROM (
+				SELECT user_id, 0 AS followers, 1 AS following FROM inserted
+				UNION ALL
+				SELECT to_user_id, 1, 0 FROM inserted
+			) deltas
+			ORDER BY user_id
+			ON CONFLICT (user_id) DO UPDATE SET
+				followers = subscription_counts.followers + EXCLUDED.followers,
+				following = subscription_counts.following + EXCLUDED.following,
+				updated_at = NOW()
+		)
+		SELECT COUNT(*) FROM inserted`
+
+	var inserted int64
+
+	err := n.db.QueryRowxContext(ctx, q, userID, toUserID, level, pq.StringArray(types)).Scan(&inserted)
 	if err != nil {
 		return err
 	}
+	if inserted == 0 {
+		return grpc_errors.ErrSubAlreadyExists
+	}
 	return nil
 }
 
 func (n *NotificationsPostgres) GetUserSubscription(ctx context.Context, userID string, toUserID string) (*domain.Subscriber, error) {
-	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.SubscribeUser")
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.GetUserSubscription")
 	defer span.End()
+	defer observeQuery("GetUserSubscription")()
 
-	q := "SELECT user_id, to_user_id, created_at, updated_at FROM subscribers WHERE user_id = $1 AND to_user_id = $2"
+	q := "SELECT id, user_id, to_user_id, created_at, updated_at, level, types FROM subscribers WHERE user_id = $1 AND to_user_id = $2"
 
 	var subscription domain.Subscriber
 
@@ -56,13 +84,14 @@ func (n *NotificationsPostgres) GetUserSubscription(ctx context.Context, userID
 
 }
 
-func (n *NotificationsPostgres) UnSubscribeFromUser(ctx context.Context, userID, toUserID string) error {
-	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.UnSubscribeFromUser")
+func (n *NotificationsPostgres) UpdateSubscriptionLevel(ctx context.Context, userID, toUserID string, level domain.SubscriptionLevel, types []string) error {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.UpdateSubscriptionLevel")
 	defer span.End()
+	defer observeQuery("UpdateSubscriptionLevel")()
 
-	q := "DELETE FROM subscribers WHERE user_id = $1 AND to_user_id = $2"
+	q := "UPDATE subscribers SET level = $3, types = $4, updated_at = NOW() WHERE user_id = $1 AND to_user_id = $2"
 
-	res, err := n.db.ExecContext(ctx, q, userID, toUserID)
+	res, err := n.db.ExecContext(ctx, q, userID, toUserID, level, pq.StringArray(types))
 	if err != nil {
 		return err
 	}
@@ -73,15 +102,83 @@ func (n *NotificationsPostgres) UnSubscribeFromUser(ctx context.Context, userID,
 	return nil
 }
 
-func (n *NotificationsPostgres) GetUserSubscribers(ctx context.Context, userID string) ([]domain.Subscriber, error) {
+func (n *NotificationsPostgres) UnSubscribeFromUser(ctx context.Context, userID, toUserID string) error {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.UnSubscribeFromUser")
+	defer span.End()
+	defer observeQuery("UnSubscribeFromUser")()
+
+	q := `WITH deleted AS (
+			DELETE FROM subscribers WHERE user_id = $1 AND to_user_id = $2
+			RETURNING user_id, to_user_id
+		), deltas AS (
+			SELECT user_id, 0 AS followers, 1 AS following FROM deleted
+			UNION ALL
+			SELECT to_user_id, 1, 0 FROM deleted
+		), locked AS (
+			SELECT c.user_id FROM subscription_counts c
+			WHERE c.user_id IN (SELECT user_id FROM deltas)
+			ORDER BY c.user_id
+			FOR UPDATE
+		), counts AS (
+			UPDATE subscription_counts c SET
+				followers = GREATEST(c.followers - d.followers, 0),
+				following = GREATEST(c.following - d.following, 0),
+				updated_at = NOW()
+			FROM deltas d
+			WHERE c.user_id = d.user_id AND c.user_id IN (SELECT user_id FROM locked)
+		)
+		SELECT COUNT(*) FROM deleted`
+
+	var deleted int64
+
+	err := n.db.QueryRowxContext(ctx, q, userID, toUserID).Scan(&deleted)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (n *NotificationsPostgres) GetUserSubscribers(ctx context.Context, userID string, cursor string) ([]domain.Subscriber, string, error) {
 	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.GetUserSubscribers")
 	defer span.End()
+	defer observeQuery("GetUserSubscribers")()
+
+	return n.listSubscriptions(ctx, "to_user_id", userID, cursor)
+}
+
+func (n *NotificationsPostgres) CountUserSubscribers(ctx context.Context, userID string) (int64, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.CountUserSubscribers")
+	defer span.End()
+	defer observeQuery("CountUserSubscribers")()
 
-	q := "SELECT user_id, to_user_id, created_at, updated_at FROM subscribers WHERE to_user_id = $1"
+	q := "SELECT COALESCE((SELECT followers FROM subscription_counts WHERE user_id = $1), 0)"
+
+	var count int64
+
+	err := sqlx.GetContext(ctx, n.db, &count, q, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (n *NotificationsPostgres) GetUserSubscribersPage(ctx context.Context, userID string, afterUserID string, limit int) ([]domain.Subscriber, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.GetUserSubscribersPage")
+	defer span.End()
+	defer observeQuery("GetUserSubscribersPage")()
+
+	q := `SELECT user_id, to_user_id, created_at, updated_at, level, types FROM subscribers
+		WHERE to_user_id = $1 AND ($2 = '' OR user_id > $2::uuid)
+		ORDER BY user_id
+		LIMIT $3`
 
 	var result []domain.Subscriber
 
-	err := sqlx.SelectContext(ctx, n.db, &result, q, userID)
+	err := sqlx.SelectContext(ctx, n.db, &result, q, userID, afterUserID, limit)
 
 	if err != nil {
 		return nil, err
@@ -90,27 +187,34 @@ func (n *NotificationsPostgres) GetUserSubscribers(ctx context.Context, userID s
 	return result, nil
 }
 
-// change add subscribe id field
 func (n *NotificationsPostgres) GetUserSubscriptions(ctx context.Context, userID string, cursor string) ([]domain.Subscriber, string, error) {
 	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.GetUserSubscriptions")
 	defer span.End()
+	defer observeQuery("GetUserSubscriptions")()
+
+	return n.listSubscriptions(ctx, "user_id", userID, cursor)
+}
 
-	var createdAt time.Time
-	var subID uuid.UUID
-	var err error
+func (n *NotificationsPostgres) listSubscriptions(ctx context.Context, column string, userID string, cursor string) ([]domain.Subscriber, string, error) {
+	var createdAt *time.Time
+	var subID *uuid.UUID
 
 	if cursor != "" {
-		createdAt, subID, err = pagination.DecodeCursor(cursor)
+		cursorCreatedAt, cursorID, err := pagination.DecodeCursor(cursor)
 		if err != nil {
 			return nil, "", err
 		}
+		createdAt, subID = &cursorCreatedAt, &cursorID
 	}
 
-	q := "SELECT user_id, to_user_id, created_at, updated_at FROM subscribers WHERE (created_at, id) > ($1, $2) AND user_id = $3 ORDER BY created_at, id LIMIT $4"
+	q := `SELECT id, user_id, to_user_id, created_at, updated_at, level, types FROM subscribers
+		WHERE ` + column + ` = $1 AND ($2::timestamptz IS NULL OR (created_at, id) > ($2, $3::uuid))
+		ORDER BY created_at, id
+		LIMIT $4`
 
 	var result []domain.Subscriber
 
-	err = sqlx.SelectContext(ctx, n.db, &result, q, createdAt, subID, paginationLimit)
+	err := sqlx.SelectContext(ctx, n.db, &result, q, userID, createdAt, subID, paginationLimit)
 
 	if err != nil {
 		return nil, "", err
@@ -118,55 +222,280 @@ func (n *NotificationsPostgres) GetUserSubscriptions(ctx context.Context, userID
 
 	var nextCursor string
 
-	if len(result) > 0 {
-		latestCreatedAt := result[len(result)-1].CreatedAt
-		nextCursor = pagination.EncodeCursor(latestCreatedAt, result[len(result)-1].ID)
+	if len(result) == paginationLimit {
+		last := result[len(result)-1]
+		nextCursor = pagination.EncodeCursor(last.CreatedAt, last.ID)
 	}
 
 	return result, nextCursor, nil
 }
 
-func (n *NotificationsPostgres) GetNotifications(ctx context.Context, userID string) ([]domain.Notification, error) {
+func (n *NotificationsPostgres) GetNotifications(ctx context.Context, userID string, cursor string, limit int) ([]domain.Notification, string, error) {
 	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.GetNotifications")
 	defer span.End()
+	defer observeQuery("GetNotifications")()
+
+	var createdAt *time.Time
+	var notificationID *uuid.UUID
+
+	if cursor != "" {
+		cursorCreatedAt, cursorID, err := pagination.DecodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		createdAt, notificationID = &cursorCreatedAt, &cursorID
+	}
 
-	q := "SELECT * FROM notifications WHERE to_user_id = $1 ORDER BY read DESC, created_at DESC LIMIT 30"
+	q := `WITH ` + inboxCTE(
+		`$2::timestamptz IS NULL OR (created_at, notification_id) < ($2, $3::uuid)`,
+		`$2::timestamptz IS NULL OR (f.created_at <= $2 AND (f.created_at, f.feed_event_id) < ($2, $3::uuid))`,
+		`ORDER BY created_at DESC, notification_id DESC LIMIT $4`,
+	) + `
+		SELECT notification_id, to_user_id, from_user_id, type, entity_kind, entity_id, metadata, read, created_at
+		FROM inbox
+		ORDER BY created_at DESC, notification_id DESC
+		LIMIT $4`
 
 	var result []domain.Notification
 
-	err := sqlx.SelectContext(ctx, n.db, &result, q, userID)
+	err := sqlx.SelectContext(ctx, n.db, &result, q, userID, createdAt, notificationID, limit)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+
+	if len(result) == limit {
+		last := result[len(result)-1]
+		nextCursor = pagination.EncodeCursor(last.CreatedAt, last.NotificationID.String())
+	}
+
+	return result, nextCursor, nil
+
+}
+
+type groupPosition struct {
+	GroupKey  string    `db:"group_key"`
+	CreatedAt time.Time `db:"created_at"`
+	ID        uuid.UUID `db:"id"`
+}
+
+type notificationGroupRow struct {
+	domain.NotificationGroup
+	GroupKey string `db:"group_key"`
+}
+
+func (n *NotificationsPostgres) GetNotificationGroups(ctx context.Context, userID string, cursor string, limit int) ([]domain.NotificationGroup, string, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.GetNotificationGroups")
+	defer span.End()
+	defer observeQuery("GetNotificationGroups")()
+
+	var createdAt *time.Time
+	var groupID *uuid.UUID
+
+	if cursor != "" {
+		cursorCreatedAt, cursorID, err := pagination.DecodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		createdAt, groupID = &cursorCreatedAt, &cursorID
+	}
+
+	// Each source only offers a group at its first notification, so every group is offered once.
+	q := `WITH personal AS (
+			SELECT g.group_key, g.first_created_at AS created_at, g.first_notification_id AS id
+			FROM notification_groups g
+			WHERE g.user_id = $1
+				AND ($2::timestamptz IS NULL OR (g.first_created_at, g.first_notification_id) < ($2, $3::uuid))
+				AND NOT EXISTS (
+					SELECT 1 FROM author_feed_groups o
+					JOIN subscribers os ON os.user_id = $1 AND os.to_user_id = o.author_id AND os.created_at <= o.first_created_at
+					WHERE o.group_key = g.group_key
+						AND (o.first_created_at, o.first_feed_event_id) < (g.first_created_at, g.first_notification_id)
+				)
+			ORDER BY g.first_created_at DESC, g.first_notification_id DESC
+			LIMIT $4
+		), feed AS (
+			SELECT a.group_key, a.first_created_at AS created_at, a.first_feed_event_id AS id
+			FROM subscribers s
+			CROSS JOIN LATERAL (
+				SELECT fg.group_key, fg.first_created_at, fg.first_feed_event_id
+				FROM author_feed_groups fg
+				WHERE fg.author_id = s.to_user_id AND fg.first_created_at >= s.created_at
+					AND ($2::timestamptz IS NULL OR (fg.first_created_at, fg.first_feed_event_id) < ($2, $3::uuid))
+					AND NOT EXISTS (
+						SELECT 1 FROM notification_groups g
+						WHERE g.user_id = $1 AND g.group_key = fg.group_key
+							AND (g.first_created_at, g.first_notification_id) < (fg.first_created_at, fg.first_feed_event_id)
+					)
+					AND NOT EXISTS (
+						SELECT 1 FROM author_feed_groups o
+						JOIN subscribers os ON os.user_id = $1 AND os.to_user_id = o.author_id AND os.created_at <= o.first_created_at
+						WHERE o.group_key = fg.group_key
+							AND (o.first_created_at, o.first_feed_event_id) < (fg.first_created_at, fg.first_feed_event_id)
+					)
+				ORDER BY fg.first_created_at DESC, fg.first_feed_event_id DESC
+				LIMIT $4
+			) a
+			WHERE s.user_id = $1
+		)
+		SELECT group_key, created_at, id FROM personal
+		UNION ALL
+		SELECT group_key, created_at, id FROM feed
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4`
+
+	var page []groupPosition
+
+	err := sqlx.SelectContext(ctx, n.db, &page, q, userID, createdAt, groupID, limit)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(page) == 0 {
+		return nil, "", nil
+	}
+
+	result, err := n.loadNotificationGroups(ctx, userID, page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+
+	if len(page) == limit {
+		last := page[len(page)-1]
+		nextCursor = pagination.EncodeCursor(last.CreatedAt, last.ID.String())
+	}
+
+	return result, nextCursor, nil
+}
+
+func (n *NotificationsPostgres) loadNotificationGroups(ctx context.Context, userID string, page []groupPosition) ([]domain.NotificationGroup, error) {
+	keys := make([]string, 0, len(page))
+	ids := make([]string, 0, len(page))
+
+	for _, position := range page {
+		keys = append(keys, position.GroupKey)
+		ids = append(ids, position.ID.String())
+	}
+
+	q := `WITH members AS (
+			SELECT notification_id, to_user_id, from_user_id, type, entity_kind, entity_id, metadata,
+				COALESCE(group_key, notification_id::text) AS group_key, read, created_at
+			FROM (
+				SELECT notification_id, to_user_id, from_user_id, type, entity_kind, entity_id, metadata, group_key, read, created_at
+				FROM notifications
+				WHERE to_user_id = $1 AND (group_key = ANY($2::text[]) OR notification_id = ANY($3::uuid[]))
+				UNION ALL
+				` + feedInboxSelect + `
+					AND (f.group_key = ANY($2::text[]) OR f.feed_event_id = ANY($3::uuid[]))
+			) m
+		), groups AS (
+			SELECT group_key,
+				(array_agg(notification_id ORDER BY created_at DESC, notification_id DESC))[1] AS latest_id,
+				COUNT(*) AS count,
+				COUNT(DISTINCT from_user_id) AS actor_count,
+				bool_and(read) AS read
+			FROM members
+			GROUP BY group_key
+		)
+		SELECT g.group_key, n.notification_id, n.to_user_id, n.from_user_id, n.type, n.entity_kind, n.entity_id, n.metadata,
+			g.read, n.created_at, g.count, g.actor_count,
+			ARRAY(
+				SELECT m.from_user_id::text FROM members m
+				WHERE m.group_key = g.group_key
+				GROUP BY m.from_user_id ORDER BY MAX(m.created_at) DESC LIMIT $4
+			) AS recent_actors
+		FROM groups g
+		JOIN members n ON n.notification_id = g.latest_id`
+
+	var rows []notificationGroupRow
+
+	err := sqlx.SelectContext(ctx, n.db, &rows, q, userID, pq.StringArray(keys), pq.StringArray(ids), recentActorsLimit)
 
 	if err != nil {
 		return nil, err
 	}
 
+	byKey := make(map[string]domain.NotificationGroup, len(rows))
+	for _, row := range rows {
+		byKey[row.GroupKey] = row.NotificationGroup
+	}
+
+	result := make([]domain.NotificationGroup, 0, len(page))
+
+	for _, position := range page {
+		if group, ok := byKey[position.GroupKey]; ok {
+			result = append(result, group)
+		}
+	}
+
 	return result, nil
+}
+
+func (n *NotificationsPostgres) GetNotificationsSince(ctx context.Context, userID string, cursor string, limit int) ([]domain.Notification, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.GetNotificationsSince")
+	defer span.End()
+	defer observeQuery("GetNotificationsSince")()
+
+	createdAt, notificationID, err := pagination.DecodeCursor(cursor)
+	if err != nil {
+		return nil, grpc_errors.ErrInvalidCursor
+	}
+
+	q := `WITH ` + inboxCTE(
+		`(created_at, notification_id) > ($2, $3::uuid)`,
+		`f.created_at >= $2 AND (f.created_at, f.feed_event_id) > ($2, $3::uuid)`,
+		`ORDER BY created_at, notification_id LIMIT $4`,
+	) + `
+		SELECT notification_id, to_user_id, from_user_id, type, entity_kind, entity_id, metadata, read, created_at
+		FROM inbox
+		ORDER BY created_at, notification_id
+		LIMIT $4`
+
+	var result []domain.Notification
+
+	err = sqlx.SelectContext(ctx, n.db, &result, q, userID, createdAt, notificationID, limit)
 
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
 }
 
-func (n *NotificationsPostgres) MarkNotificationAsRead(ctx context.Context, userID string, notificationID string) error {
-	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.GetNotifications")
+func (n *NotificationsPostgres) MarkNotificationAsRead(ctx context.Context, userID string, notificationID string) (int64, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.MarkNotificationAsRead")
 	defer span.End()
+	defer observeQuery("MarkNotificationAsRead")()
 
-	q := "UPDATE notifications SET read = TRUE WHERE to_user_id = $1 AND notification_id = $2"
+	q := `UPDATE notifications SET read = TRUE
+		WHERE to_user_id = $1 AND read = FALSE AND (notification_id = $2 OR group_key = COALESCE(
+			(SELECT group_key FROM notifications WHERE to_user_id = $1 AND notification_id = $2),
+			(SELECT group_key FROM author_feed WHERE feed_event_id = $2)
+		))`
 
 	res, err := n.db.ExecContext(ctx, q, userID, notificationID)
 
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	rows, _ := res.RowsAffected()
-	if rows == 0 {
-		return sql.ErrNoRows
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
 	}
 
-	return nil
+	return rows, nil
 }
 
 func (n *NotificationsPostgres) ReadAllNotifications(ctx context.Context, userID string) error {
 	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.ReadAllNotifications")
 	defer span.End()
+	defer observeQuery("ReadAllNotifications")()
 
 	q := "UPDATE notifications SET read = TRUE WHERE to_user_id = $1"
 
@@ -184,28 +513,115 @@ func (n *NotificationsPostgres) ReadAllNotifications(ctx context.Context, userID
 	return nil
 }
 
-func (n *NotificationsPostgres) BatchAddNotification(ctx context.Context, subscribers []domain.Subscriber, input domain.IncomingNewNotification) error {
+func (n *NotificationsPostgres) CountUnreadNotifications(ctx context.Context, userID string) (int64, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.CountUnreadNotifications")
+	defer span.End()
+	defer observeQuery("CountUnreadNotifications")()
+
+	q := "SELECT COUNT(*) FROM notifications WHERE to_user_id = $1 AND read = FALSE"
+
+	var count int64
+
+	err := n.db.QueryRowxContext(ctx, q, userID).Scan(&count)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+const batchAddNotificationQuery = `WITH inserted AS (
+		INSERT INTO notifications (to_user_id, from_user_id, type, entity_kind, entity_id, metadata, group_key, event_id)
+		SELECT recipient, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, '')
+		FROM unnest($1::uuid[]) AS recipient
+		ON CONFLICT (event_id, to_user_id) DO NOTHING
+		RETURNING notification_id, to_user_id, from_user_id, type, entity_kind, entity_id, metadata, read, created_at
+	), outbox AS (
+		INSERT INTO notification_outbox (event_type, payload)
+		SELECT $9, to_jsonb(inserted) FROM inserted
+	), groups AS (
+		INSERT INTO notification_groups (user_id, group_key, first_created_at, first_notification_id)
+		SELECT to_user_id, COALESCE(NULLIF($7, ''), notification_id::text), created_at, notification_id FROM inserted
+		ON CONFLICT (user_id, group_key) DO NOTHING
+	)
+	SELECT notification_id, to_user_id, from_user_id, type, entity_kind, entity_id, metadata, read, created_at FROM inserted`
+
+func (n *NotificationsPostgres) BatchAddNotification(ctx context.Context, subscribers []domain.Subscriber, input domain.IncomingNewNotification) ([]domain.Notification, error) {
 	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.BatchAddNotification")
 	defer span.End()
+	defer observeQuery("BatchAddNotification")()
+
+	if len(subscribers) == 0 {
+		return nil, nil
+	}
+
+	recipients := make([]string, 0, len(subscribers))
+	for _, sub := range subscribers {
+		recipients = append(recipients, sub.UserID)
+	}
+
+	metadata := input.Metadata
+	if len(metadata) == 0 {
+		metadata = types.JSONText("{}")
+	}
+
+	var result []domain.Notification
+
+	err := sqlx.SelectContext(ctx, n.db, &result, batchAddNotificationQuery, pq.StringArray(recipients), input.SenderID, input.Type, input.Entity.Kind, input.Entity.ID, metadata, input.GroupKey, input.EventID, domain.OutboxNotificationCreated)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (n *NotificationsPostgres) SaveFanOutCheckpoint(ctx context.Context, eventID string, lastRecipientID string) error {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.SaveFanOutCheckpoint")
+	defer span.End()
+	defer observeQuery("SaveFanOutCheckpoint")()
 
-	tx, err := n.db.BeginTx(ctx, nil)
+	q := `INSERT INTO fanout_checkpoints (event_id, last_recipient_id) VALUES ($1, $2)
+		ON CONFLICT (event_id) DO UPDATE SET last_recipient_id = GREATEST(fanout_checkpoints.last_recipient_id, EXCLUDED.last_recipient_id), updated_at = NOW()`
+
+	_, err := n.db.ExecContext(ctx, q, eventID, lastRecipientID)
 	if err != nil {
 		return err
 	}
 
-	q := "INSERT INTO notifications (to_user_id, from_user_id, type) VALUES ($1, $2, $3)"
+	return nil
+}
 
-	for _, sub := range subscribers {
-		_, err = tx.ExecContext(ctx, q, sub.UserID, input.SenderID, input.Type)
-		if err != nil {
-			if rollbackErr := tx.Rollback(); rollbackErr != nil {
-				return rollbackErr
-			}
-			return err
-		}
+func (n *NotificationsPostgres) GetFanOutCheckpoint(ctx context.Context, eventID string) (string, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.GetFanOutCheckpoint")
+	defer span.End()
+	defer observeQuery("GetFanOutCheckpoint")()
+
+	q := "SELECT last_recipient_id FROM fanout_checkpoints WHERE event_id = $1"
+
+	var lastRecipient string
+
+	err := n.db.QueryRowxContext(ctx, q, eventID).Scan(&lastRecipient)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", err
 	}
 
-	err = tx.Commit()
+	return lastRecipient, nil
+}
+
+func (n *NotificationsPostgres) DeleteFanOutCheckpoint(ctx context.Context, eventID string) error {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.DeleteFanOutCheckpoint")
+	defer span.End()
+	defer observeQuery("DeleteFanOutCheckpoint")()
+
+	q := "DELETE FROM fanout_checkpoints WHERE event_id = $1"
+
+	_, err := n.db.ExecContext(ctx, q, eventID)
 	if err != nil {
 		return err
 	}
@@ -216,12 +632,15 @@ func (n *NotificationsPostgres) BatchAddNotification(ctx context.Context, subscr
 func (n *NotificationsPostgres) GetNotificationByID(ctx context.Context, userID string, notificationID string) (domain.Notification, error) {
 	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.GetNotificationByID")
 	defer span.End()
+	defer observeQuery("GetNotificationByID")()
 
-	q := "SELECT * FROM notifications WHERE notification_id = $1 AND to_user_id = $2"
+	q := `WITH ` + inboxCTE(`notification_id = $2`, `f.feed_event_id = $2`, ``) + `
+		SELECT notification_id, to_user_id, from_user_id, type, entity_kind, entity_id, metadata, read, created_at
+		FROM inbox`
 
 	var notification domain.Notification
 
-	err := n.db.QueryRowxContext(ctx, q, notificationID, userID).StructScan(&notification)
+	err := n.db.QueryRowxContext(ctx, q, userID, notificationID).StructScan(&notification)
 
 	if errors.Is(err, sql.ErrNoRows) {
 		return domain.Notification{}, sql.ErrNoRows
@@ -0,0 +1,324 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Verce11o/yata-notifications/internal/domain"
+	"github.com/Verce11o/yata-notifications/internal/lib/grpc_errors"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/types"
+	"github.com/lib/pq"
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testSubscribers(n int) []domain.Subscriber {
+	result := make([]domain.Subscriber, 0, n)
+	for i := 0; i < n; i++ {
+		result = append(result, domain.Subscriber{UserID: uuid.NewString()})
+	}
+	return result
+}
+
+func testNotification() domain.IncomingNewNotification {
+	return domain.IncomingNewNotification{
+		EventID:  uuid.NewString(),
+		SenderID: uuid.New(),
+		Type:     "like",
+		Entity:   domain.Entity{Kind: "post", ID: uuid.NewString()},
+	}
+}
+
+func insertPerRow(ctx context.Context, db *sqlx.DB, subscribers []domain.Subscriber, input domain.IncomingNewNotification) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, sub := range subscribers {
+		_, err := tx.ExecContext(ctx, batchAddNotificationQuery, pq.StringArray{sub.UserID}, input.SenderID, input.Type, input.Entity.Kind, input.Entity.ID, types.JSONText("{}"), input.GroupKey, input.EventID, domain.OutboxNotificationCreated)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func BenchmarkFanOutInsert(b *testing.B) {
+	repo, db := newTestRepository(b)
+	ctx := context.Background()
+
+	for _, size := range []int{100, 1000, 10000} {
+		subscribers := testSubscribers(size)
+
+		b.Run(fmt.Sprintf("per_row/%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := insertPerRow(ctx, db, subscribers, testNotification()); err != nil {
+					b.Fatalf("cannot insert notifications: %v", err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("unnest/%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := repo.BatchAddNotification(ctx, subscribers, testNotification()); err != nil {
+					b.Fatalf("cannot insert notifications: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestSubscribeToUserConcurrently(t *testing.T) {
+	repo, db := newTestRepository(t)
+	ctx := context.Background()
+
+	userID, toUserID := uuid.NewString(), uuid.NewString()
+
+	const attempts = 20
+
+	errs := make(chan error, attempts)
+	start := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			errs <- repo.SubscribeToUser(ctx, userID, toUserID, domain.SubscriptionLevelAll, nil)
+		}()
+	}
+
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case errors.Is(err, grpc_errors.ErrSubAlreadyExists):
+		default:
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if succeeded != 1 {
+		t.Fatalf("%d subscribe calls succeeded, want 1", succeeded)
+	}
+
+	var rows int
+	if err := db.Get(&rows, "SELECT COUNT(*) FROM subscribers WHERE user_id = $1 AND to_user_id = $2", userID, toUserID); err != nil {
+		t.Fatalf("cannot count subscriptions: %v", err)
+	}
+
+	if rows != 1 {
+		t.Fatalf("%d subscription rows, want 1", rows)
+	}
+
+	var followers int64
+	if err := db.Get(&followers, "SELECT followers FROM subscription_counts WHERE user_id = $1", toUserID); err != nil {
+		t.Fatalf("cannot get follower count: %v", err)
+	}
+
+	if followers != 1 {
+		t.Fatalf("follower count is %d, want 1", followers)
+	}
+}
+
+type subscriptionPage func(ctx context.Context, userID string, cursor string) ([]domain.Subscriber, string, error)
+
+func readAllPages(t *testing.T, userID string, pages subscriptionPage, between func()) []string {
+	t.Helper()
+
+	var ids []string
+	cursor := ""
+
+	for {
+		page, next, err := pages(context.Background(), userID, cursor)
+		if err != nil {
+			t.Fatalf("cannot read page: %v", err)
+		}
+
+		for _, sub := range page {
+			ids = append(ids, sub.ID)
+		}
+
+		if next == "" {
+			return ids
+		}
+
+		if len(page) != paginationLimit {
+			t.Fatalf("page of %d rows has a next cursor", len(page))
+		}
+
+		cursor = next
+		between()
+	}
+}
+
+func TestSubscriptionPagingIsStable(t *testing.T) {
+	repo, db := newTestRepository(t)
+
+	userID := uuid.NewString()
+	rows := 2*paginationLimit + paginationLimit/2
+
+	// Half of the rows share a timestamp, so pages have to break ties by ID.
+	q := `INSERT INTO subscribers (user_id, to_user_id, created_at)
+		SELECT $1, uuid_generate_v4(), CASE WHEN i % 2 = 0 THEN $3::timestamptz ELSE $3::timestamptz + i * INTERVAL '1 millisecond' END
+		FROM generate_series(1, $2) AS i`
+	if _, err := db.Exec(q, userID, rows, time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("cannot insert subscriptions: %v", err)
+	}
+
+	q = `INSERT INTO subscribers (user_id, to_user_id, created_at)
+		SELECT uuid_generate_v4(), $1, CASE WHEN i % 2 = 0 THEN $3::timestamptz ELSE $3::timestamptz + i * INTERVAL '1 millisecond' END
+		FROM generate_series(1, $2) AS i`
+	if _, err := db.Exec(q, userID, rows, time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("cannot insert subscribers: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		pages  subscriptionPage
+		column string
+		add    func() error
+	}{
+		{
+			name:   "subscriptions",
+			pages:  repo.GetUserSubscriptions,
+			column: "user_id",
+			add: func() error {
+				return repo.SubscribeToUser(context.Background(), userID, uuid.NewString(), domain.SubscriptionLevelAll, nil)
+			},
+		},
+		{
+			name:   "subscribers",
+			pages:  repo.GetUserSubscribers,
+			column: "to_user_id",
+			add: func() error {
+				return repo.SubscribeToUser(context.Background(), uuid.NewString(), userID, domain.SubscriptionLevelAll, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var want []string
+			q := "SELECT id FROM subscribers WHERE " + tt.column + " = $1 ORDER BY created_at, id"
+			if err := db.Select(&want, q, userID); err != nil {
+				t.Fatalf("cannot list rows: %v", err)
+			}
+
+			// Rows added while paging sort after the ones already there and must not shift them.
+			added := 0
+			got := readAllPages(t, userID, tt.pages, func() {
+				if err := tt.add(); err != nil {
+					t.Fatalf("cannot subscribe: %v", err)
+				}
+				added++
+			})
+
+			seen := make(map[string]struct{}, len(got))
+			for _, id := range got {
+				if _, ok := seen[id]; ok {
+					t.Fatalf("row %s returned twice", id)
+				}
+				seen[id] = struct{}{}
+			}
+
+			if len(got) != len(want)+added {
+				t.Fatalf("paged through %d rows, want %d", len(got), len(want)+added)
+			}
+
+			for i, id := range want {
+				if got[i] != id {
+					t.Fatalf("row %d is %s, want %s", i, got[i], id)
+				}
+			}
+		})
+	}
+}
+
+func TestSubscriptionPagingFullLastPage(t *testing.T) {
+	repo, db := newTestRepository(t)
+	ctx := context.Background()
+
+	userID := uuid.NewString()
+
+	q := `INSERT INTO subscribers (user_id, to_user_id) SELECT $1, uuid_generate_v4() FROM generate_series(1, $2)`
+	if _, err := db.Exec(q, userID, paginationLimit); err != nil {
+		t.Fatalf("cannot insert subscriptions: %v", err)
+	}
+
+	page, next, err := repo.GetUserSubscriptions(ctx, userID, "")
+	if err != nil {
+		t.Fatalf("cannot read first page: %v", err)
+	}
+
+	if len(page) != paginationLimit || next == "" {
+		t.Fatalf("first page has %d rows and cursor %q, want %d rows and a cursor", len(page), next, paginationLimit)
+	}
+
+	page, next, err = repo.GetUserSubscriptions(ctx, userID, next)
+	if err != nil {
+		t.Fatalf("cannot read page after the full last page: %v", err)
+	}
+
+	if len(page) != 0 || next != "" {
+		t.Fatalf("page after the full last page has %d rows and cursor %q, want none", len(page), next)
+	}
+}
+
+func TestRedesignSubscribersMigrationRemovesDuplicates(t *testing.T) {
+	const (
+		before   = 20240214100000
+		redesign = 20240217100000
+	)
+
+	db := openTestDB(t)
+	migrate(t, db, 0, before)
+
+	userID, first, second := uuid.NewString(), uuid.NewString(), uuid.NewString()
+
+	q := `INSERT INTO subscribers (user_id, to_user_id) VALUES ($1, $2), ($1, $2), ($1, $2), ($1, $3)`
+	if _, err := db.Exec(q, userID, first, second); err != nil {
+		t.Fatalf("cannot insert duplicate subscriptions: %v", err)
+	}
+
+	migrate(t, db, before, redesign)
+
+	var pairs []struct {
+		ToUserID string `db:"to_user_id"`
+		Count    int    `db:"count"`
+	}
+	q = "SELECT to_user_id, COUNT(*) AS count FROM subscribers WHERE user_id = $1 GROUP BY to_user_id"
+	if err := db.Select(&pairs, q, userID); err != nil {
+		t.Fatalf("cannot count subscriptions: %v", err)
+	}
+
+	if len(pairs) != 2 {
+		t.Fatalf("%d distinct subscriptions left, want 2", len(pairs))
+	}
+
+	for _, pair := range pairs {
+		if pair.Count != 1 {
+			t.Fatalf("subscription to %s has %d rows, want 1", pair.ToUserID, pair.Count)
+		}
+	}
+
+	_, err := db.Exec("INSERT INTO subscribers (user_id, to_user_id) VALUES ($1, $2)", userID, first)
+	if err == nil {
+		t.Fatal("duplicate subscription was inserted after the migration")
+	}
+
+	migrate(t, db, redesign, math.MaxInt64)
+}
@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+	"github.com/Verce11o/yata-notifications/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"time"
+)
+
+func (n *NotificationsPostgres) ClaimOutboxMessages(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.OutboxMessage, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.ClaimOutboxMessages")
+	defer span.End()
+	defer observeQuery("ClaimOutboxMessages")()
+
+	q := `WITH pending AS (
+			SELECT id FROM notification_outbox
+			WHERE locked_until <= $1
+			ORDER BY created_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE notification_outbox o SET locked_until = $2, attempts = o.attempts + 1
+		FROM pending
+		WHERE o.id = pending.id
+		RETURNING o.id, o.event_type, o.payload, o.attempts, o.created_at`
+
+	var result []domain.OutboxMessage
+
+	err := sqlx.SelectContext(ctx, n.db, &result, q, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (n *NotificationsPostgres) DeleteOutboxMessages(ctx context.Context, ids []uuid.UUID) error {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.DeleteOutboxMessages")
+	defer span.End()
+	defer observeQuery("DeleteOutboxMessages")()
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	values := make([]string, 0, len(ids))
+	for _, id := range ids {
+		values = append(values, id.String())
+	}
+
+	q := "DELETE FROM notification_outbox WHERE id = ANY($1::uuid[])"
+
+	_, err := n.db.ExecContext(ctx, q, pq.StringArray(values))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel/trace/noop"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// testDSNEnv names a database whose public schema the tests drop and recreate.
+const testDSNEnv = "NOTIFICATIONS_TEST_POSTGRES_DSN"
+
+const migrationsDir = "../../../migrations"
+
+type migration struct {
+	version int64
+	name    string
+	up      string
+}
+
+func openTestDB(tb testing.TB) *sqlx.DB {
+	tb.Helper()
+
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		tb.Skipf("%s is not set", testDSNEnv)
+	}
+
+	db, err := sqlx.Open("postgres", dsn)
+	if err != nil {
+		tb.Fatalf("cannot open test database: %v", err)
+	}
+	tb.Cleanup(func() {
+		db.Close()
+	})
+
+	_, err = db.Exec(`DROP SCHEMA public CASCADE;
+		CREATE SCHEMA public;
+		CREATE EXTENSION IF NOT EXISTS "uuid-ossp"`)
+	if err != nil {
+		tb.Fatalf("cannot reset test database: %v", err)
+	}
+
+	return db
+}
+
+func newTestRepository(tb testing.TB) (*NotificationsPostgres, *sqlx.DB) {
+	tb.Helper()
+
+	db := openTestDB(tb)
+	migrate(tb, db, 0, math.MaxInt64)
+
+	return NewNotificationsPostgres(db, noop.NewTracerProvider().Tracer("test")), db
+}
+
+func migrate(tb testing.TB, db *sqlx.DB, from int64, to int64) {
+	tb.Helper()
+
+	for _, m := range loadMigrations(tb) {
+		if m.version <= from || m.version > to {
+			continue
+		}
+
+		if _, err := db.Exec(m.up); err != nil {
+			tb.Fatalf("cannot apply migration %s: %v", m.name, err)
+		}
+	}
+}
+
+func loadMigrations(tb testing.TB) []migration {
+	tb.Helper()
+
+	files, err := filepath.Glob(filepath.Join(migrationsDir, "*.sql"))
+	if err != nil {
+		tb.Fatalf("cannot list migrations: %v", err)
+	}
+	sort.Strings(files)
+
+	result := make([]migration, 0, len(files))
+
+	for _, file := range files {
+		name := filepath.Base(file)
+
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			tb.Fatalf("cannot parse version of migration %s: %v", name, err)
+		}
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			tb.Fatalf("cannot read migration %s: %v", name, err)
+		}
+
+		_, up, ok := strings.Cut(string(content), "-- +goose Up")
+		if !ok {
+			tb.Fatalf("migration %s has no goose Up section", name)
+		}
+		up, _, _ = strings.Cut(up, "-- +goose Down")
+
+		result = append(result, migration{version: version, name: name, up: up})
+	}
+
+	return result
+}
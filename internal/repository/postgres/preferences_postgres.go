@@ -0,0 +1,205 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/Verce11o/yata-notifications/internal/domain"
+	"github.com/lib/pq"
+	"time"
+)
+
+func (n *NotificationsPostgres) GetPreferences(ctx context.Context, userID string) (domain.Preferences, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.GetPreferences")
+	defer span.End()
+	defer observeQuery("GetPreferences")()
+
+	q := "SELECT user_id, disabled_types, muted_senders, muted_until, quiet_hours_start, quiet_hours_end, timezone, updated_at FROM notification_preferences WHERE user_id = $1"
+
+	var preferences domain.Preferences
+
+	err := n.db.QueryRowxContext(ctx, q, userID).StructScan(&preferences)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Preferences{}, sql.ErrNoRows
+	}
+
+	if err != nil {
+		return domain.Preferences{}, err
+	}
+
+	return preferences, nil
+}
+
+func (n *NotificationsPostgres) UpdatePreferences(ctx context.Context, preferences domain.Preferences) (domain.Preferences, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.UpdatePreferences")
+	defer span.End()
+	defer observeQuery("UpdatePreferences")()
+
+	q := `INSERT INTO notification_preferences (user_id, disabled_types, muted_senders, muted_until, quiet_hours_start, quiet_hours_end, timezone)
+		VALUES ($1, COALESCE($2::text[], '{}'), COALESCE($3::uuid[], '{}'), $4, $5, $6, $7)
+		ON CONFLICT (user_id) DO UPDATE SET
+			disabled_types = EXCLUDED.disabled_types,
+			muted_senders = EXCLUDED.muted_senders,
+			muted_until = EXCLUDED.muted_until,
+			quiet_hours_start = EXCLUDED.quiet_hours_start,
+			quiet_hours_end = EXCLUDED.quiet_hours_end,
+			timezone = EXCLUDED.timezone,
+			updated_at = NOW()
+		RETURNING user_id, disabled_types, muted_senders, muted_until, quiet_hours_start, quiet_hours_end, timezone, updated_at`
+
+	var result domain.Preferences
+
+	err := n.db.QueryRowxContext(ctx, q, preferences.UserID, preferences.DisabledTypes,
+		preferences.MutedSenders, preferences.MutedUntil, preferences.QuietHoursStart, preferences.QuietHoursEnd,
+		preferences.Timezone).StructScan(&result)
+
+	if err != nil {
+		return domain.Preferences{}, err
+	}
+
+	return result, nil
+}
+
+func (n *NotificationsPostgres) DeletePreferences(ctx context.Context, userID string) error {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.DeletePreferences")
+	defer span.End()
+	defer observeQuery("DeletePreferences")()
+
+	q := "DELETE FROM notification_preferences WHERE user_id = $1"
+
+	_, err := n.db.ExecContext(ctx, q, userID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (n *NotificationsPostgres) SetNotificationTypeEnabled(ctx context.Context, userID string, notificationType string, enabled bool) error {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.SetNotificationTypeEnabled")
+	defer span.End()
+	defer observeQuery("SetNotificationTypeEnabled")()
+
+	q := `INSERT INTO notification_preferences AS p (user_id, disabled_types)
+		VALUES ($1, CASE WHEN $3 THEN '{}'::text[] ELSE ARRAY[$2::text] END)
+		ON CONFLICT (user_id) DO UPDATE SET
+			disabled_types = CASE WHEN $3 THEN array_remove(p.disabled_types, $2::text)
+				ELSE array_append(array_remove(p.disabled_types, $2::text), $2::text) END,
+			updated_at = NOW()`
+
+	_, err := n.db.ExecContext(ctx, q, userID, notificationType, enabled)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (n *NotificationsPostgres) MuteSender(ctx context.Context, userID string, senderID string) error {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.MuteSender")
+	defer span.End()
+	defer observeQuery("MuteSender")()
+
+	q := `INSERT INTO notification_preferences AS p (user_id, muted_senders)
+		VALUES ($1, ARRAY[$2::uuid])
+		ON CONFLICT (user_id) DO UPDATE SET
+			muted_senders = array_append(array_remove(p.muted_senders, $2::uuid), $2::uuid),
+			updated_at = NOW()`
+
+	_, err := n.db.ExecContext(ctx, q, userID, senderID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (n *NotificationsPostgres) UnmuteSender(ctx context.Context, userID string, senderID string) error {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.UnmuteSender")
+	defer span.End()
+	defer observeQuery("UnmuteSender")()
+
+	q := "UPDATE notification_preferences SET muted_senders = array_remove(muted_senders, $2::uuid), updated_at = NOW() WHERE user_id = $1"
+
+	_, err := n.db.ExecContext(ctx, q, userID, senderID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (n *NotificationsPostgres) MuteAll(ctx context.Context, userID string, until *time.Time) error {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.MuteAll")
+	defer span.End()
+	defer observeQuery("MuteAll")()
+
+	q := `INSERT INTO notification_preferences (user_id, muted_until) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET muted_until = EXCLUDED.muted_until, updated_at = NOW()`
+
+	_, err := n.db.ExecContext(ctx, q, userID, until)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (n *NotificationsPostgres) SetQuietHours(ctx context.Context, userID string, start string, end string, timezone string) error {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.SetQuietHours")
+	defer span.End()
+	defer observeQuery("SetQuietHours")()
+
+	q := `INSERT INTO notification_preferences (user_id, quiet_hours_start, quiet_hours_end, timezone) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET
+			quiet_hours_start = EXCLUDED.quiet_hours_start,
+			quiet_hours_end = EXCLUDED.quiet_hours_end,
+			timezone = EXCLUDED.timezone,
+			updated_at = NOW()`
+
+	_, err := n.db.ExecContext(ctx, q, userID, start, end, timezone)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (n *NotificationsPostgres) GetQuietHoursPreferences(ctx context.Context, userIDs []string) ([]domain.Preferences, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.GetQuietHoursPreferences")
+	defer span.End()
+	defer observeQuery("GetQuietHoursPreferences")()
+
+	q := `SELECT user_id, disabled_types, muted_senders, muted_until, quiet_hours_start, quiet_hours_end, timezone, updated_at
+		FROM notification_preferences
+		WHERE user_id = ANY($1::uuid[]) AND quiet_hours_start <> ''`
+
+	var result []domain.Preferences
+
+	err := n.db.SelectContext(ctx, &result, q, pq.Array(userIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (n *NotificationsPostgres) GetMutedRecipients(ctx context.Context, userIDs []string, senderID string, notificationType string) ([]string, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.GetMutedRecipients")
+	defer span.End()
+	defer observeQuery("GetMutedRecipients")()
+
+	q := `SELECT user_id FROM notification_preferences
+		WHERE user_id = ANY($1::uuid[])
+			AND ($2 = ANY(disabled_types) OR $3::uuid = ANY(muted_senders) OR muted_until > NOW())`
+
+	var result []string
+
+	err := n.db.SelectContext(ctx, &result, q, pq.Array(userIDs), notificationType, senderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
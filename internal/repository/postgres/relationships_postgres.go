@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"github.com/Verce11o/yata-notifications/internal/domain"
+	"github.com/Verce11o/yata-notifications/internal/lib/pagination"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"time"
+)
+
+func (n *NotificationsPostgres) GetRelationships(ctx context.Context, userID string, targetUserIDs []string) ([]domain.Relationship, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.GetRelationships")
+	defer span.End()
+	defer observeQuery("GetRelationships")()
+
+	q := `SELECT t.user_id,
+			EXISTS (SELECT 1 FROM subscribers s WHERE s.user_id = $1 AND s.to_user_id = t.user_id) AS following,
+			EXISTS (SELECT 1 FROM subscribers s WHERE s.user_id = t.user_id AND s.to_user_id = $1) AS followed_by
+		FROM unnest($2::uuid[]) WITH ORDINALITY AS t(user_id, position)
+		ORDER BY t.position`
+
+	var result []domain.Relationship
+
+	err := sqlx.SelectContext(ctx, n.db, &result, q, userID, pq.StringArray(targetUserIDs))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (n *NotificationsPostgres) GetMutualFollowers(ctx context.Context, userID string, cursor string) ([]domain.Subscriber, string, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.GetMutualFollowers")
+	defer span.End()
+	defer observeQuery("GetMutualFollowers")()
+
+	var createdAt *time.Time
+	var subID *uuid.UUID
+
+	if cursor != "" {
+		cursorCreatedAt, cursorID, err := pagination.DecodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		createdAt, subID = &cursorCreatedAt, &cursorID
+	}
+
+	q := `SELECT s.id, s.user_id, s.to_user_id, s.created_at, s.updated_at, s.level, s.types FROM subscribers s
+		WHERE s.to_user_id = $1
+			AND EXISTS (SELECT 1 FROM subscribers b WHERE b.user_id = $1 AND b.to_user_id = s.user_id)
+			AND ($2::timestamptz IS NULL OR (s.created_at, s.id) > ($2, $3::uuid))
+		ORDER BY s.created_at, s.id
+		LIMIT $4`
+
+	var result []domain.Subscriber
+
+	err := sqlx.SelectContext(ctx, n.db, &result, q, userID, createdAt, subID, paginationLimit)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+
+	if len(result) == paginationLimit {
+		last := result[len(result)-1]
+		nextCursor = pagination.EncodeCursor(last.CreatedAt, last.ID)
+	}
+
+	return result, nextCursor, nil
+}
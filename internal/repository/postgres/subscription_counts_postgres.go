@@ -0,0 +1,104 @@
+package postgres
+
+import (
+	"context"
+	"github.com/Verce11o/yata-notifications/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+func (n *NotificationsPostgres) GetSubscriptionCounts(ctx context.Context, userIDs []string) ([]domain.SubscriptionCounts, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.GetSubscriptionCounts")
+	defer span.End()
+	defer observeQuery("GetSubscriptionCounts")()
+
+	q := "SELECT user_id, followers, following FROM subscription_counts WHERE user_id = ANY($1::uuid[])"
+
+	var result []domain.SubscriptionCounts
+
+	err := sqlx.SelectContext(ctx, n.db, &result, q, pq.StringArray(userIDs))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (n *NotificationsPostgres) ReconcileSubscriptionCounts(ctx context.Context, afterUserID string, limit int) (string, int64, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationsPostgres.ReconcileSubscriptionCounts")
+	defer span.End()
+	defer observeQuery("ReconcileSubscriptionCounts")()
+
+	if afterUserID == "" {
+		afterUserID = uuid.Nil.String()
+	}
+
+	q := `SELECT user_id FROM (
+			(SELECT user_id FROM subscription_counts WHERE user_id > $1::uuid ORDER BY user_id LIMIT $2)
+			UNION
+			(SELECT DISTINCT user_id FROM subscribers WHERE user_id > $1::uuid ORDER BY user_id LIMIT $2)
+			UNION
+			(SELECT DISTINCT to_user_id FROM subscribers WHERE to_user_id > $1::uuid ORDER BY to_user_id LIMIT $2)
+		) candidates
+		ORDER BY user_id
+		LIMIT $2`
+
+	var userIDs []string
+
+	if err := sqlx.SelectContext(ctx, n.db, &userIDs, q, afterUserID, limit); err != nil {
+		return "", 0, err
+	}
+
+	if len(userIDs) == 0 {
+		return "", 0, nil
+	}
+
+	tx, err := n.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", 0, err
+	}
+	defer tx.Rollback()
+
+	q = `INSERT INTO subscription_counts (user_id)
+		SELECT user_id FROM unnest($1::uuid[]) AS u(user_id) ORDER BY user_id
+		ON CONFLICT (user_id) DO NOTHING`
+
+	if _, err := tx.ExecContext(ctx, q, pq.StringArray(userIDs)); err != nil {
+		return "", 0, err
+	}
+
+	// Concurrent subscribes wait on these locks, so the recount neither misses nor repeats them.
+	q = "SELECT user_id FROM subscription_counts WHERE user_id = ANY($1::uuid[]) ORDER BY user_id FOR UPDATE"
+
+	if _, err := tx.ExecContext(ctx, q, pq.StringArray(userIDs)); err != nil {
+		return "", 0, err
+	}
+
+	q = `UPDATE subscription_counts c
+		SET followers = actual.followers, following = actual.following, updated_at = NOW()
+		FROM (
+			SELECT u.user_id,
+				(SELECT COUNT(*) FROM subscribers s WHERE s.to_user_id = u.user_id) AS followers,
+				(SELECT COUNT(*) FROM subscribers s WHERE s.user_id = u.user_id) AS following
+			FROM unnest($1::uuid[]) AS u(user_id)
+		) actual
+		WHERE c.user_id = actual.user_id AND (c.followers <> actual.followers OR c.following <> actual.following)`
+
+	res, err := tx.ExecContext(ctx, q, pq.StringArray(userIDs))
+	if err != nil {
+		return "", 0, err
+	}
+
+	fixed, err := res.RowsAffected()
+	if err != nil {
+		return "", 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", 0, err
+	}
+
+	return userIDs[len(userIDs)-1], fixed, nil
+}
@@ -0,0 +1,30 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+func (n *NotificationRedis) GetLatestFeedEventAt(ctx context.Context, userID string) (time.Time, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationRedis.GetLatestFeedEventAt")
+	defer span.End()
+
+	nanos, err := n.client.Get(ctx, n.createLatestFeedEventKey(userID)).Int64()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(0, nanos), nil
+}
+
+func (n *NotificationRedis) SetLatestFeedEventAt(ctx context.Context, userID string, latest time.Time, ttl time.Duration) error {
+	ctx, span := n.tracer.Start(ctx, "notificationRedis.SetLatestFeedEventAt")
+	defer span.End()
+
+	return n.client.Set(ctx, n.createLatestFeedEventKey(userID), latest.UnixNano(), ttl).Err()
+}
+
+func (n *NotificationRedis) createLatestFeedEventKey(userID string) string {
+	return fmt.Sprintf("latest_feed_event:%s", userID)
+}
@@ -0,0 +1,30 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+func (n *NotificationRedis) IsEventProcessed(ctx context.Context, eventID string) (bool, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationRedis.IsEventProcessed")
+	defer span.End()
+
+	exists, err := n.client.Exists(ctx, n.createProcessedEventKey(eventID)).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return exists == 1, nil
+}
+
+func (n *NotificationRedis) MarkEventProcessed(ctx context.Context, eventID string, ttl time.Duration) error {
+	ctx, span := n.tracer.Start(ctx, "notificationRedis.MarkEventProcessed")
+	defer span.End()
+
+	return n.client.Set(ctx, n.createProcessedEventKey(eventID), 1, ttl).Err()
+}
+
+func (n *NotificationRedis) createProcessedEventKey(eventID string) string {
+	return fmt.Sprintf("processed_event:%s", eventID)
+}
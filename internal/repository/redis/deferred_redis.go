@@ -0,0 +1,65 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Verce11o/yata-notifications/internal/domain"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+const (
+	deferredEventsKey = "deferred_events"
+)
+
+var popDueEventsScript = redis.NewScript(`
+local items = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+if #items > 0 then
+	redis.call('ZREM', KEYS[1], unpack(items))
+end
+return items
+`)
+
+func (n *NotificationRedis) DeferEvents(ctx context.Context, events []domain.DeferredEvent) error {
+	ctx, span := n.tracer.Start(ctx, "notificationRedis.DeferEvents")
+	defer span.End()
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	members := make([]redis.Z, 0, len(events))
+
+	for _, event := range events {
+		eventBytes, err := json.Marshal(event.Event)
+		if err != nil {
+			return err
+		}
+		members = append(members, redis.Z{Score: float64(event.ReleaseAt.Unix()), Member: string(eventBytes)})
+	}
+
+	return n.client.ZAdd(ctx, deferredEventsKey, members...).Err()
+}
+
+// PopDueEvents also counts the popped items that could not be decoded.
+func (n *NotificationRedis) PopDueEvents(ctx context.Context, now time.Time, limit int) ([]domain.Event, int, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationRedis.PopDueEvents")
+	defer span.End()
+
+	items, err := popDueEventsScript.Run(ctx, n.client, []string{deferredEventsKey}, now.Unix(), limit).StringSlice()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	result := make([]domain.Event, 0, len(items))
+
+	for _, item := range items {
+		var event domain.Event
+		if err := json.Unmarshal([]byte(item), &event); err != nil {
+			continue
+		}
+		result = append(result, event)
+	}
+
+	return result, len(items), nil
+}
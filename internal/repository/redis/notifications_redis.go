@@ -3,10 +3,8 @@ package redis
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"github.com/Verce11o/yata-notifications/internal/domain"
-	"github.com/Verce11o/yata-notifications/internal/lib/grpc_errors"
 	"github.com/redis/go-redis/v9"
 	"go.opentelemetry.io/otel/trace"
 	"time"
@@ -25,39 +23,46 @@ func NewNotificationRedis(client *redis.Client, tracer trace.Tracer) *Notificati
 	return &NotificationRedis{client: client, tracer: tracer}
 }
 
-func (n *NotificationRedis) GetNotificationsByUserID(ctx context.Context, key string) ([]domain.Notification, error) {
+type cachedPage struct {
+	Notifications []domain.NotificationGroup `json:"notifications"`
+	Cursor        string                     `json:"cursor"`
+}
+
+func (n *NotificationRedis) GetNotificationsByUserID(ctx context.Context, key string, cursor string, limit int) ([]domain.NotificationGroup, string, error) {
 	ctx, span := n.tracer.Start(ctx, "notificationRedis.GetNotificationsByID")
 	defer span.End()
 
-	notificationBytes, err := n.client.Get(ctx, n.createKey(key)).Bytes()
+	pageBytes, err := n.client.HGet(ctx, n.createKey(key), n.createPageField(cursor, limit)).Bytes()
 
 	if err != nil {
-		if !errors.Is(err, redis.Nil) {
-			return nil, grpc_errors.ErrNotFound
-		}
-		return nil, err
+		return nil, "", err
 	}
 
-	var notifications []domain.Notification
-	if err := json.Unmarshal(notificationBytes, &notifications); err != nil {
-		return nil, err
+	var page cachedPage
+	if err := json.Unmarshal(pageBytes, &page); err != nil {
+		return nil, "", err
 	}
 
-	return notifications, nil
+	return page.Notifications, page.Cursor, nil
 
 }
 
-func (n *NotificationRedis) SetNotificationsByUserID(ctx context.Context, key string, notifications []domain.Notification) error {
+func (n *NotificationRedis) SetNotificationsByUserID(ctx context.Context, key string, cursor string, limit int, notifications []domain.NotificationGroup, nextCursor string) error {
 	ctx, span := n.tracer.Start(ctx, "notificationRedis.SetNotificationsByUserID")
 	defer span.End()
 
-	notificationBytes, err := json.Marshal(notifications)
+	pageBytes, err := json.Marshal(cachedPage{Notifications: notifications, Cursor: nextCursor})
 
 	if err != nil {
 		return err
 	}
 
-	return n.client.Set(ctx, n.createKey(key), notificationBytes, time.Second*time.Duration(notificationTTL)).Err()
+	pipe := n.client.TxPipeline()
+	pipe.HSet(ctx, n.createKey(key), n.createPageField(cursor, limit), pageBytes)
+	pipe.Expire(ctx, n.createKey(key), time.Second*time.Duration(notificationTTL))
+
+	_, err = pipe.Exec(ctx)
+	return err
 
 }
 
@@ -72,3 +77,7 @@ func (n *NotificationRedis) DeleteNotificationsByUserID(ctx context.Context, key
 func (n *NotificationRedis) createKey(key string) string {
 	return fmt.Sprintf("notification:%s", key)
 }
+
+func (n *NotificationRedis) createPageField(cursor string, limit int) string {
+	return fmt.Sprintf("%d:%s", limit, cursor)
+}
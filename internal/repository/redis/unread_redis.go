@@ -0,0 +1,102 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+const (
+	unreadCountTTL = 86400
+)
+
+// incrUnreadScript returns -1 for a missing counter, which is rebuilt on the next read.
+var incrUnreadScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return -1
+end
+local value = redis.call('INCRBY', KEYS[1], ARGV[1])
+if value < 0 then
+	value = 0
+	redis.call('SET', KEYS[1], 0, 'KEEPTTL')
+end
+return value
+`)
+
+func (n *NotificationRedis) GetUnreadCount(ctx context.Context, key string) (int64, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationRedis.GetUnreadCount")
+	defer span.End()
+
+	return n.client.Get(ctx, n.createUnreadKey(key)).Int64()
+}
+
+func (n *NotificationRedis) SetUnreadCount(ctx context.Context, key string, count int64) error {
+	ctx, span := n.tracer.Start(ctx, "notificationRedis.SetUnreadCount")
+	defer span.End()
+
+	return n.client.Set(ctx, n.createUnreadKey(key), count, time.Second*time.Duration(unreadCountTTL)).Err()
+}
+
+// RebuildUnreadCount keeps and returns a counter cached since the recount started.
+func (n *NotificationRedis) RebuildUnreadCount(ctx context.Context, key string, count int64) (int64, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationRedis.RebuildUnreadCount")
+	defer span.End()
+
+	unreadKey := n.createUnreadKey(key)
+
+	ok, err := n.client.SetNX(ctx, unreadKey, count, time.Second*time.Duration(unreadCountTTL)).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	if ok {
+		return count, nil
+	}
+
+	cached, err := n.client.Get(ctx, unreadKey).Int64()
+	if errors.Is(err, redis.Nil) {
+		return count, nil
+	}
+
+	return cached, err
+}
+
+func (n *NotificationRedis) IncrUnreadCount(ctx context.Context, keys []string, delta int64) (map[string]int64, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationRedis.IncrUnreadCount")
+	defer span.End()
+
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	pipe := n.client.Pipeline()
+
+	cmds := make([]*redis.Cmd, 0, len(keys))
+	for _, key := range keys {
+		cmds = append(cmds, incrUnreadScript.Eval(ctx, pipe, []string{n.createUnreadKey(key)}, delta))
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]int64, len(cmds))
+	for i, cmd := range cmds {
+		value, err := cmd.Int64()
+		if err != nil {
+			return nil, err
+		}
+
+		if value >= 0 {
+			result[keys[i]] = value
+		}
+	}
+
+	return result, nil
+}
+
+func (n *NotificationRedis) createUnreadKey(key string) string {
+	return fmt.Sprintf("unread_notifications:%s", key)
+}
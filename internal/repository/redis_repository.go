@@ -3,10 +3,21 @@ package repository
 import (
 	"context"
 	"github.com/Verce11o/yata-notifications/internal/domain"
+	"time"
 )
 
 type RedisRepository interface {
-	GetNotificationsByUserID(ctx context.Context, key string) ([]domain.Notification, error)
-	SetNotificationsByUserID(ctx context.Context, key string, notifications []domain.Notification) error
+	GetNotificationsByUserID(ctx context.Context, key string, cursor string, limit int) ([]domain.NotificationGroup, string, error)
+	SetNotificationsByUserID(ctx context.Context, key string, cursor string, limit int, notifications []domain.NotificationGroup, nextCursor string) error
 	DeleteNotificationsByUserID(ctx context.Context, key string) error
+	GetUnreadCount(ctx context.Context, key string) (int64, error)
+	SetUnreadCount(ctx context.Context, key string, count int64) error
+	RebuildUnreadCount(ctx context.Context, key string, count int64) (int64, error)
+	IncrUnreadCount(ctx context.Context, keys []string, delta int64) (map[string]int64, error)
+	DeferEvents(ctx context.Context, events []domain.DeferredEvent) error
+	PopDueEvents(ctx context.Context, now time.Time, limit int) ([]domain.Event, int, error)
+	IsEventProcessed(ctx context.Context, eventID string) (bool, error)
+	MarkEventProcessed(ctx context.Context, eventID string, ttl time.Duration) error
+	GetLatestFeedEventAt(ctx context.Context, userID string) (time.Time, error)
+	SetLatestFeedEventAt(ctx context.Context, userID string, latest time.Time, ttl time.Duration) error
 }
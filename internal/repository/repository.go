@@ -3,25 +3,79 @@ package repository
 import (
 	"context"
 	"github.com/Verce11o/yata-notifications/internal/domain"
+	"github.com/google/uuid"
+	"time"
 )
 
 type Subscribe interface {
-	SubscribeToUser(ctx context.Context, userID, toUserID string) error
+	SubscribeToUser(ctx context.Context, userID, toUserID string, level domain.SubscriptionLevel, types []string) error
+	UpdateSubscriptionLevel(ctx context.Context, userID, toUserID string, level domain.SubscriptionLevel, types []string) error
 	GetUserSubscription(ctx context.Context, userID string, toUserID string) (*domain.Subscriber, error)
 	GetUserSubscriptions(ctx context.Context, userID string, cursor string) ([]domain.Subscriber, string, error)
 	UnSubscribeFromUser(ctx context.Context, userID, toUserID string) error
-	GetUserSubscribers(ctx context.Context, userID string) ([]domain.Subscriber, error)
+	GetUserSubscribers(ctx context.Context, userID string, cursor string) ([]domain.Subscriber, string, error)
+	GetUserSubscribersPage(ctx context.Context, userID string, afterUserID string, limit int) ([]domain.Subscriber, error)
+	CountUserSubscribers(ctx context.Context, userID string) (int64, error)
+	GetSubscriptionCounts(ctx context.Context, userIDs []string) ([]domain.SubscriptionCounts, error)
+	ReconcileSubscriptionCounts(ctx context.Context, afterUserID string, limit int) (string, int64, error)
+	GetRelationships(ctx context.Context, userID string, targetUserIDs []string) ([]domain.Relationship, error)
+	GetMutualFollowers(ctx context.Context, userID string, cursor string) ([]domain.Subscriber, string, error)
 }
 
 type Notification interface {
 	GetNotificationByID(ctx context.Context, userID string, notificationID string) (domain.Notification, error)
-	BatchAddNotification(ctx context.Context, subscribers []domain.Subscriber, input domain.IncomingNewNotification) error
-	GetNotifications(ctx context.Context, userID string) ([]domain.Notification, error)
-	MarkNotificationAsRead(ctx context.Context, userID string, notificationID string) error
+	BatchAddNotification(ctx context.Context, subscribers []domain.Subscriber, input domain.IncomingNewNotification) ([]domain.Notification, error)
+	GetFanOutCheckpoint(ctx context.Context, eventID string) (string, error)
+	SaveFanOutCheckpoint(ctx context.Context, eventID string, lastRecipientID string) error
+	DeleteFanOutCheckpoint(ctx context.Context, eventID string) error
+	GetNotifications(ctx context.Context, userID string, cursor string, limit int) ([]domain.Notification, string, error)
+	GetNotificationGroups(ctx context.Context, userID string, cursor string, limit int) ([]domain.NotificationGroup, string, error)
+	GetNotificationsSince(ctx context.Context, userID string, cursor string, limit int) ([]domain.Notification, error)
+	MarkNotificationAsRead(ctx context.Context, userID string, notificationID string) (int64, error)
 	ReadAllNotifications(ctx context.Context, userID string) error
+	CountUnreadNotifications(ctx context.Context, userID string) (int64, error)
+}
+
+type Preferences interface {
+	GetPreferences(ctx context.Context, userID string) (domain.Preferences, error)
+	UpdatePreferences(ctx context.Context, preferences domain.Preferences) (domain.Preferences, error)
+	DeletePreferences(ctx context.Context, userID string) error
+	SetNotificationTypeEnabled(ctx context.Context, userID string, notificationType string, enabled bool) error
+	MuteSender(ctx context.Context, userID string, senderID string) error
+	UnmuteSender(ctx context.Context, userID string, senderID string) error
+	MuteAll(ctx context.Context, userID string, until *time.Time) error
+	GetMutedRecipients(ctx context.Context, userIDs []string, senderID string, notificationType string) ([]string, error)
+	SetQuietHours(ctx context.Context, userID string, start string, end string, timezone string) error
+	GetQuietHoursPreferences(ctx context.Context, userIDs []string) ([]domain.Preferences, error)
+}
+
+type Digest interface {
+	SubscribeToDigest(ctx context.Context, subscription domain.DigestSubscription) error
+	UnsubscribeFromDigest(ctx context.Context, userID string) error
+	ClaimDueDigests(ctx context.Context, now time.Time, limit int) ([]domain.DigestSubscription, error)
+	ResetDigestSentAt(ctx context.Context, userID string, lastSentAt time.Time) error
+	GetUnreadNotificationsSince(ctx context.Context, userID string, since time.Time, limit int) ([]domain.Notification, error)
+}
+
+type AuthorFeed interface {
+	AddAuthorFeedEvent(ctx context.Context, input domain.IncomingNewNotification) (domain.Notification, bool, error)
+	GetFeedAuthors(ctx context.Context, userID string, minFollowers int64) ([]domain.Subscriber, error)
+	MarkFeedEventsAsRead(ctx context.Context, userID string, notificationID string) (int64, error)
+	ReadAllFeedEvents(ctx context.Context, userID string) (int64, error)
+	CountUnreadFeedEvents(ctx context.Context, userIDs []string, since time.Time) (map[string]int64, error)
+	GetLatestFeedEventAt(ctx context.Context, userID string) (time.Time, error)
+}
+
+type Outbox interface {
+	ClaimOutboxMessages(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.OutboxMessage, error)
+	DeleteOutboxMessages(ctx context.Context, ids []uuid.UUID) error
 }
 
 type Repository interface {
 	Subscribe
 	Notification
+	Preferences
+	Digest
+	AuthorFeed
+	Outbox
 }
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/Verce11o/yata-notifications/internal/domain"
+	pb "github.com/Verce11o/yata-protos/gen/go/notifications"
+	"github.com/lib/pq"
+	"time"
+)
+
+const (
+	defaultAggregationWindow = time.Hour
+)
+
+// groupKey aligns windows to the epoch, so every replica computes the same key.
+func (n *NotificationsService) groupKey(notification domain.IncomingNewNotification, now time.Time) string {
+	window := n.cfg.Aggregation.Window
+	if window <= 0 {
+		window = defaultAggregationWindow
+	}
+
+	return fmt.Sprintf("%s:%s:%s:%d", notification.Type, notification.Entity.Kind, notification.Entity.ID, now.UnixNano()/int64(window))
+}
+
+func (n *NotificationsService) getNotificationGroups(ctx context.Context, userID string, cursor string, limit int) ([]domain.NotificationGroup, string, error) {
+	if n.cfg.Aggregation.Enabled {
+		return n.repo.GetNotificationGroups(ctx, userID, cursor, limit)
+	}
+
+	notifications, nextCursor, err := n.repo.GetNotifications(ctx, userID, cursor, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	groups := make([]domain.NotificationGroup, 0, len(notifications))
+
+	for _, notification := range notifications {
+		groups = append(groups, domain.NotificationGroup{
+			Notification: notification,
+			Count:        1,
+			ActorCount:   1,
+			RecentActors: pq.StringArray{notification.FromUserID.String()},
+		})
+	}
+
+	return groups, nextCursor, nil
+}
+
+func domainToNotificationGroupPb(groups []domain.NotificationGroup) []*pb.Notification {
+	result := make([]*pb.Notification, 0, len(groups))
+
+	for _, group := range groups {
+		notification := domainToNotificationPb([]domain.Notification{group.Notification})[0]
+		notification.Count = group.Count
+		notification.ActorCount = group.ActorCount
+		notification.RecentActorIds = group.RecentActors
+
+		result = append(result, notification)
+	}
+
+	return result
+}
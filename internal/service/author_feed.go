@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Verce11o/yata-notifications/internal/domain"
+	"github.com/Verce11o/yata-notifications/internal/lib/pagination"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	defaultFeedCursorTTL    = 10 * time.Second
+	defaultFeedUnreadWindow = 7 * 24 * time.Hour
+)
+
+func (n *NotificationsService) isHybridAuthor(ctx context.Context, authorID string) (bool, error) {
+	if n.cfg.FanOut.HybridThreshold <= 0 {
+		return false, nil
+	}
+
+	count, err := n.repo.CountUserSubscribers(ctx, authorID)
+	if err != nil {
+		return false, err
+	}
+
+	return count >= n.cfg.FanOut.HybridThreshold, nil
+}
+
+func (n *NotificationsService) storeAuthorFeedEvent(ctx context.Context, notification domain.IncomingNewNotification) (int, int, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationService.storeAuthorFeedEvent")
+	defer span.End()
+
+	stored, ok, err := n.repo.AddAuthorFeedEvent(ctx, notification)
+	if err != nil {
+		return 1, 0, err
+	}
+
+	if !ok {
+		return 1, 0, nil
+	}
+
+	event := domain.Event{
+		ID:           pagination.EncodeCursor(stored.CreatedAt, stored.NotificationID.String()),
+		Type:         domain.EventNotificationCreated,
+		Notification: &stored,
+	}
+
+	if err := n.bus.PublishFeed(ctx, stored.FromUserID.String(), event); err != nil {
+		n.log.Errorf("cannot publish feed event: %v", err.Error())
+	}
+
+	return 1, 1, nil
+}
+
+func (n *NotificationsService) withFeedEvents(ctx context.Context, userID string, live <-chan domain.Event) <-chan domain.Event {
+	if n.cfg.FanOut.HybridThreshold <= 0 {
+		return live
+	}
+
+	recipient, err := uuid.Parse(userID)
+	if err != nil {
+		return live
+	}
+
+	authors, err := n.repo.GetFeedAuthors(ctx, userID, n.cfg.FanOut.HybridThreshold)
+	if err != nil {
+		n.log.Errorf("cannot get feed authors: %v", err.Error())
+		return live
+	}
+
+	if len(authors) == 0 {
+		return live
+	}
+
+	preferences, err := n.repo.GetPreferences(ctx, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		n.log.Errorf("cannot get preferences: %v", err.Error())
+		return live
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	feeds := make([]<-chan domain.Event, 0, len(authors))
+	for _, author := range authors {
+		feed, err := n.bus.SubscribeFeed(ctx, author.ToUserID)
+		if err != nil {
+			cancel()
+			n.log.Errorf("cannot subscribe to feed events: %v", err.Error())
+			return live
+		}
+		feeds = append(feeds, feed)
+	}
+
+	result := make(chan domain.Event, replayBufferSize)
+
+	var wg sync.WaitGroup
+
+	// The merged stream ends as soon as any source does, so the subscriber resyncs.
+	forward := func(events <-chan domain.Event, accept func(event domain.Event) (domain.Event, bool)) {
+		defer wg.Done()
+		defer cancel()
+
+		for {
+			var event domain.Event
+			var ok bool
+
+			select {
+			case event, ok = <-events:
+				if !ok {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+
+			if event, ok = accept(event); !ok {
+				continue
+			}
+
+			select {
+			case result <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+
+	wg.Add(1 + len(feeds))
+
+	go forward(live, func(event domain.Event) (domain.Event, bool) {
+		return event, true
+	})
+
+	for i, feed := range feeds {
+		author := authors[i]
+		go forward(feed, func(event domain.Event) (domain.Event, bool) {
+			if event.Notification == nil || !feedEventVisible(author, preferences, *event.Notification, time.Now()) {
+				return event, false
+			}
+
+			notification := *event.Notification
+			notification.ToUserID = recipient
+			event.UserID = userID
+			event.Notification = &notification
+
+			return event, true
+		})
+	}
+
+	go func() {
+		wg.Wait()
+		close(result)
+	}()
+
+	return result
+}
+
+func feedEventVisible(subscription domain.Subscriber, preferences domain.Preferences, notification domain.Notification, now time.Time) bool {
+	if !subscription.Receives(notification.Type) || notification.CreatedAt.Before(subscription.CreatedAt) {
+		return false
+	}
+
+	if slices.Contains(preferences.DisabledTypes, notification.Type) || slices.Contains(preferences.MutedSenders, notification.FromUserID.String()) {
+		return false
+	}
+
+	if preferences.MutedUntil != nil && preferences.MutedUntil.After(notification.CreatedAt) {
+		return false
+	}
+
+	if _, quiet := quietHoursEnd(preferences, now); quiet {
+		return false
+	}
+
+	return true
+}
+
+func (n *NotificationsService) withFeedUnread(ctx context.Context, counts map[string]int64) map[string]int64 {
+	if n.cfg.FanOut.HybridThreshold <= 0 || len(counts) == 0 {
+		return counts
+	}
+
+	userIDs := make([]string, 0, len(counts))
+	for userID := range counts {
+		userIDs = append(userIDs, userID)
+	}
+
+	feedCounts, err := n.repo.CountUnreadFeedEvents(ctx, userIDs, time.Now().Add(-n.feedUnreadWindow()))
+	if err != nil {
+		n.log.Errorf("cannot count unread feed events: %v", err.Error())
+		return counts
+	}
+
+	result := make(map[string]int64, len(counts))
+	for userID, count := range counts {
+		result[userID] = count + feedCounts[userID]
+	}
+
+	return result
+}
+
+func (n *NotificationsService) feedUnreadWindow() time.Duration {
+	if n.cfg.FanOut.FeedUnreadWindow <= 0 {
+		return defaultFeedUnreadWindow
+	}
+	return n.cfg.FanOut.FeedUnreadWindow
+}
+
+func (n *NotificationsService) notificationsCacheCursor(ctx context.Context, userID string, cursor string) string {
+	if n.cfg.FanOut.HybridThreshold <= 0 {
+		return cursor
+	}
+
+	latest, err := n.redis.GetLatestFeedEventAt(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			n.log.Errorf("cannot get cached latest feed event: %v", err.Error())
+		}
+
+		latest, err = n.repo.GetLatestFeedEventAt(ctx, userID)
+		if err != nil {
+			n.log.Errorf("cannot get latest feed event: %v", err.Error())
+			return cursor
+		}
+
+		ttl := n.cfg.FanOut.FeedCursorTTL
+		if ttl <= 0 {
+			ttl = defaultFeedCursorTTL
+		}
+
+		if err := n.redis.SetLatestFeedEventAt(ctx, userID, latest, ttl); err != nil {
+			n.log.Errorf("cannot cache latest feed event: %v", err.Error())
+		}
+	}
+
+	return fmt.Sprintf("%s@%d", cursor, latest.UnixNano())
+}
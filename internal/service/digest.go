@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"github.com/Verce11o/yata-notifications/config"
+	"github.com/Verce11o/yata-notifications/internal/digest"
+	"github.com/Verce11o/yata-notifications/internal/domain"
+	"github.com/Verce11o/yata-notifications/internal/lib/grpc_errors"
+	"github.com/Verce11o/yata-notifications/internal/repository"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"net/mail"
+	"sort"
+	"time"
+)
+
+const (
+	defaultDigestInterval  = 5 * time.Minute
+	digestBatchSize        = 100
+	digestNotificationsCap = 1000
+)
+
+type DigestService struct {
+	log      *zap.SugaredLogger
+	tracer   trace.Tracer
+	repo     repository.Repository
+	renderer *digest.Renderer
+	sender   digest.Sender
+	cfg      config.Digest
+}
+
+func NewDigestService(log *zap.SugaredLogger, tracer trace.Tracer, repo repository.Repository, renderer *digest.Renderer, sender digest.Sender, cfg config.Digest) *DigestService {
+	return &DigestService{log: log, tracer: tracer, repo: repo, renderer: renderer, sender: sender, cfg: cfg}
+}
+
+func (d *DigestService) Run(ctx context.Context) {
+	interval := d.cfg.Interval
+	if interval <= 0 {
+		interval = defaultDigestInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.sendDueDigests(ctx)
+		}
+	}
+}
+
+func (d *DigestService) sendDueDigests(ctx context.Context) {
+	ctx, span := d.tracer.Start(ctx, "digestService.sendDueDigests")
+	defer span.End()
+
+	for {
+		now := time.Now()
+
+		subscriptions, err := d.repo.ClaimDueDigests(ctx, now, digestBatchSize)
+		if err != nil {
+			d.log.Errorf("cannot claim due digests: %v", err.Error())
+			return
+		}
+
+		failed := false
+
+		for _, subscription := range subscriptions {
+			if err := d.sendDigest(ctx, subscription, now); err != nil {
+				d.log.Errorf("cannot send digest to %s: %v", subscription.UserID, err.Error())
+				failed = true
+
+				if err := d.repo.ResetDigestSentAt(ctx, subscription.UserID, subscription.LastSentAt); err != nil {
+					d.log.Errorf("cannot reset digest of %s: %v", subscription.UserID, err.Error())
+				}
+			}
+		}
+
+		// Reset digests are due again at once; retry them on the next tick, not in a tight loop.
+		if failed || len(subscriptions) < digestBatchSize {
+			return
+		}
+	}
+}
+
+func (d *DigestService) sendDigest(ctx context.Context, subscription domain.DigestSubscription, now time.Time) error {
+	ctx, span := d.tracer.Start(ctx, "digestService.sendDigest")
+	defer span.End()
+
+	notifications, err := d.repo.GetUnreadNotificationsSince(ctx, subscription.UserID, subscription.LastSentAt, digestNotificationsCap)
+	if err != nil {
+		return err
+	}
+
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	document, err := d.renderer.Render(buildDigest(subscription, notifications, now))
+	if err != nil {
+		return err
+	}
+
+	return d.sender.Send(ctx, document)
+}
+
+func buildDigest(subscription domain.DigestSubscription, notifications []domain.Notification, now time.Time) domain.Digest {
+	type groupKey struct {
+		notificationType string
+		senderID         string
+	}
+
+	groups := make(map[groupKey]*domain.DigestGroup)
+	order := make([]groupKey, 0)
+
+	for _, notification := range notifications {
+		key := groupKey{notificationType: notification.Type, senderID: notification.FromUserID.String()}
+
+		group, ok := groups[key]
+		if !ok {
+			group = &domain.DigestGroup{Type: key.notificationType, SenderID: key.senderID}
+			groups[key] = group
+			order = append(order, key)
+		}
+
+		group.Count++
+		if notification.CreatedAt.After(group.LatestAt) {
+			group.LatestAt = notification.CreatedAt
+		}
+	}
+
+	result := domain.Digest{
+		UserID:    subscription.UserID,
+		Email:     subscription.Email,
+		Frequency: subscription.Frequency,
+		Since:     subscription.LastSentAt,
+		Until:     now,
+		Total:     len(notifications),
+		Capped:    len(notifications) >= digestNotificationsCap,
+		Groups:    make([]domain.DigestGroup, 0, len(order)),
+	}
+
+	for _, key := range order {
+		result.Groups = append(result.Groups, *groups[key])
+	}
+
+	sort.SliceStable(result.Groups, func(i, j int) bool {
+		return result.Groups[i].Count > result.Groups[j].Count
+	})
+
+	return result
+}
+
+func (n *NotificationsService) SubscribeToDigest(ctx context.Context, userID string, frequency string, email string) error {
+	ctx, span := n.tracer.Start(ctx, "notificationService.SubscribeToDigest")
+	defer span.End()
+
+	if frequency != domain.DigestDaily && frequency != domain.DigestWeekly {
+		return grpc_errors.ErrInvalidDigest
+	}
+
+	addr, err := mail.ParseAddress(email)
+	if err != nil {
+		return grpc_errors.ErrInvalidDigest
+	}
+
+	err = n.repo.SubscribeToDigest(ctx, domain.DigestSubscription{UserID: userID, Email: addr.Address, Frequency: frequency})
+
+	if err != nil {
+		n.log.Errorf("cannot subscribe to digest: %v", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (n *NotificationsService) UnsubscribeFromDigest(ctx context.Context, userID string) error {
+	ctx, span := n.tracer.Start(ctx, "notificationService.UnsubscribeFromDigest")
+	defer span.End()
+
+	err := n.repo.UnsubscribeFromDigest(ctx, userID)
+
+	if err != nil {
+		n.log.Errorf("cannot unsubscribe from digest: %v", err.Error())
+		return err
+	}
+
+	return nil
+}
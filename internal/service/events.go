@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"github.com/Verce11o/yata-notifications/internal/domain"
+	"github.com/Verce11o/yata-notifications/internal/lib/pagination"
+	pb "github.com/Verce11o/yata-protos/gen/go/notifications"
+)
+
+const (
+	replayPageSize   = 100
+	replayBufferSize = 64
+)
+
+func (n *NotificationsService) SubscribeEvents(ctx context.Context, userID string, lastEventID string) (<-chan domain.Event, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationService.SubscribeEvents")
+	defer span.End()
+
+	if lastEventID == "" {
+		live, err := n.bus.Subscribe(ctx, userID)
+		if err != nil {
+			n.log.Errorf("cannot subscribe to user events: %v", err.Error())
+			return nil, err
+		}
+		return n.withFeedEvents(ctx, userID, live), nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	// Subscribe before loading the replay, so that nothing published in between is lost.
+	live, err := n.bus.Subscribe(ctx, userID)
+	if err != nil {
+		cancel()
+		n.log.Errorf("cannot subscribe to user events: %v", err.Error())
+		return nil, err
+	}
+	live = n.withFeedEvents(ctx, userID, live)
+
+	missed, err := n.repo.GetNotificationsSince(ctx, userID, lastEventID, replayPageSize)
+	if err != nil {
+		cancel()
+		n.log.Errorf("cannot get missed notifications: %v", err.Error())
+		return nil, err
+	}
+
+	result := make(chan domain.Event, replayBufferSize)
+
+	go func() {
+		defer cancel()
+		defer close(result)
+
+		// Events published while the replay was loading may already be in the replay.
+		replayed := make(map[string]struct{}, len(missed))
+
+		for {
+			events := notificationEvents(missed)
+
+			for _, event := range events {
+				replayed[event.ID] = struct{}{}
+				select {
+				case result <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			if len(missed) < replayPageSize {
+				break
+			}
+
+			missed, err = n.repo.GetNotificationsSince(ctx, userID, events[len(events)-1].ID, replayPageSize)
+			if err != nil {
+				n.log.Errorf("cannot get missed notifications: %v", err.Error())
+				return
+			}
+		}
+
+		for event := range live {
+			if _, ok := replayed[event.ID]; ok && event.ID != "" {
+				continue
+			}
+			select {
+			case result <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return result, nil
+}
+
+func (n *NotificationsService) StreamNotifications(ctx context.Context, userID string, send func(notification *pb.Notification) error) error {
+	events, err := n.SubscribeEvents(ctx, userID, "")
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+
+			if event.Type != domain.EventNotificationCreated || event.Notification == nil {
+				continue
+			}
+
+			if err := send(domainToNotificationPb([]domain.Notification{*event.Notification})[0]); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (n *NotificationsService) publishUnreadCounts(ctx context.Context, counts map[string]int64) {
+	if len(counts) == 0 {
+		return
+	}
+
+	result := make([]domain.Event, 0, len(counts))
+
+	for userID, count := range counts {
+		count := count
+		result = append(result, domain.Event{
+			Type:        domain.EventUnreadCountChanged,
+			UserID:      userID,
+			UnreadCount: &count,
+		})
+	}
+
+	if err := n.publishEvents(ctx, result); err != nil {
+		n.log.Errorf("cannot publish unread count events: %v", err.Error())
+	}
+}
+
+func notificationEvents(notifications []domain.Notification) []domain.Event {
+	result := make([]domain.Event, 0, len(notifications))
+
+	for i := range notifications {
+		notification := notifications[i]
+		result = append(result, domain.Event{
+			ID:           pagination.EncodeCursor(notification.CreatedAt, notification.NotificationID.String()),
+			Type:         domain.EventNotificationCreated,
+			UserID:       notification.ToUserID.String(),
+			Notification: &notification,
+		})
+	}
+
+	return result
+}
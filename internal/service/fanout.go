@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"github.com/Verce11o/yata-notifications/internal/domain"
+	"github.com/Verce11o/yata-notifications/internal/metrics/prom"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultFanOutChunkSize   = 1000
+	defaultFanOutConcurrency = 4
+)
+
+type subscriberPages func(ctx context.Context, afterUserID string, limit int) ([]domain.Subscriber, error)
+
+func slicePages(subscribers []domain.Subscriber) subscriberPages {
+	return func(ctx context.Context, afterUserID string, limit int) ([]domain.Subscriber, error) {
+		start := sort.Search(len(subscribers), func(i int) bool {
+			return subscribers[i].UserID > afterUserID
+		})
+
+		end := start + limit
+		if end > len(subscribers) {
+			end = len(subscribers)
+		}
+
+		return subscribers[start:end], nil
+	}
+}
+
+type storeFunc func(ctx context.Context, notification domain.IncomingNewNotification) (int, int, error)
+
+func (n *NotificationsService) fanOutTo(pages subscriberPages) storeFunc {
+	return func(ctx context.Context, notification domain.IncomingNewNotification) (int, int, error) {
+		return n.fanOut(ctx, notification, pages)
+	}
+}
+
+func (n *NotificationsService) notify(ctx context.Context, notification domain.IncomingNewNotification, store storeFunc) error {
+	if notification.DedupKey != "" {
+		processed, err := n.redis.IsEventProcessed(ctx, notification.DedupKey)
+		if err != nil {
+			n.log.Errorf("cannot check processed event: %v", err.Error())
+		}
+
+		if processed {
+			n.log.Infof("skipping already processed event %s", notification.DedupKey)
+			prom.DedupHits.WithLabelValues("redis").Inc()
+			return nil
+		}
+	}
+
+	if n.cfg.Aggregation.Enabled {
+		notification.GroupKey = n.groupKey(notification, time.Now())
+	}
+
+	recipients, stored, err := store(ctx, notification)
+	if err != nil {
+		n.log.Errorf("cannot add new notification: %v", err.Error())
+		return err
+	}
+
+	if recipients > 0 && stored == 0 {
+		n.log.Infof("event %s was already stored", notification.EventID)
+		prom.DedupHits.WithLabelValues("postgres").Inc()
+	}
+
+	n.markEventProcessed(ctx, notification.DedupKey)
+
+	return nil
+}
+
+func (n *NotificationsService) fanOut(ctx context.Context, notification domain.IncomingNewNotification, pages subscriberPages) (int, int, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationService.fanOut")
+	defer span.End()
+
+	after := ""
+
+	if notification.EventID != "" {
+		checkpoint, err := n.repo.GetFanOutCheckpoint(ctx, notification.EventID)
+		if err != nil {
+			n.log.Errorf("cannot get fan-out checkpoint: %v", err.Error())
+			return 0, 0, err
+		}
+
+		if checkpoint != "" {
+			n.log.Infof("resuming fan-out of event %s after %s", notification.EventID, checkpoint)
+			after = checkpoint
+		}
+	}
+
+	chunkSize := n.cfg.FanOut.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultFanOutChunkSize
+	}
+
+	concurrency := n.cfg.FanOut.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultFanOutConcurrency
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg         sync.WaitGroup
+		mu         sync.Mutex
+		firstErr   error
+		recipients int
+		stored     int
+		finished   = make(map[int]string)
+		nextPage   int
+	)
+
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	sem := make(chan struct{}, concurrency)
+
+	for index := 0; ; index++ {
+		page, err := pages(ctx, after, chunkSize)
+		if err != nil {
+			fail(err)
+			break
+		}
+
+		if len(page) == 0 {
+			break
+		}
+
+		after = page[len(page)-1].UserID
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			fail(ctx.Err())
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(index int, page []domain.Subscriber, last string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			kept, written, err := n.storePage(ctx, page, notification)
+			if err != nil {
+				fail(err)
+				return
+			}
+
+			mu.Lock()
+
+			recipients += kept
+			stored += written
+			finished[index] = last
+
+			checkpoint := ""
+			for {
+				last, ok := finished[nextPage]
+				if !ok {
+					break
+				}
+				delete(finished, nextPage)
+				nextPage++
+				checkpoint = last
+			}
+
+			mu.Unlock()
+
+			// The stored checkpoint never moves backwards, so saves may land out of order.
+			if checkpoint != "" && notification.EventID != "" {
+				if err := n.repo.SaveFanOutCheckpoint(ctx, notification.EventID, checkpoint); err != nil {
+					n.log.Errorf("cannot save fan-out checkpoint: %v", err.Error())
+				}
+			}
+		}(index, page, after)
+
+		if len(page) < chunkSize {
+			break
+		}
+	}
+
+	wg.Wait()
+
+	prom.FanOutSize.Observe(float64(recipients))
+
+	if firstErr != nil {
+		return recipients, stored, firstErr
+	}
+
+	if notification.EventID != "" {
+		if err := n.repo.DeleteFanOutCheckpoint(ctx, notification.EventID); err != nil {
+			n.log.Errorf("cannot delete fan-out checkpoint: %v", err.Error())
+		}
+	}
+
+	return recipients, stored, nil
+}
+
+func (n *NotificationsService) storePage(ctx context.Context, page []domain.Subscriber, notification domain.IncomingNewNotification) (int, int, error) {
+	page = filterSubscriptionLevels(page, notification.Type)
+
+	page, err := n.filterMutedRecipients(ctx, page, notification)
+	if err != nil {
+		n.log.Errorf("cannot apply notification preferences: %v", err.Error())
+		return 0, 0, err
+	}
+
+	if len(page) == 0 {
+		return 0, 0, nil
+	}
+
+	notifications, err := n.repo.BatchAddNotification(ctx, page, notification)
+	if err != nil {
+		return len(page), 0, err
+	}
+
+	if err := n.deliverStored(ctx, notifications); err != nil {
+		return len(page), len(notifications), err
+	}
+
+	return len(page), len(notifications), nil
+}
+
+func filterSubscriptionLevels(page []domain.Subscriber, notificationType string) []domain.Subscriber {
+	kept := make([]domain.Subscriber, 0, len(page))
+
+	for _, subscriber := range page {
+		if subscriber.Receives(notificationType) {
+			kept = append(kept, subscriber)
+		}
+	}
+
+	return kept
+}
+
+func (n *NotificationsService) deliverStored(ctx context.Context, notifications []domain.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	userIDs := make([]string, 0, len(notifications))
+
+	for _, stored := range notifications {
+		userID := stored.ToUserID.String()
+		if err := n.redis.DeleteNotificationsByUserID(ctx, userID); err != nil {
+			n.log.Errorf("cannot delete user notification cache: %v", err.Error())
+			return err
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	counts, err := n.redis.IncrUnreadCount(ctx, userIDs, 1)
+	if err != nil {
+		n.log.Errorf("cannot increment unread count: %v", err.Error())
+	}
+
+	if err := n.publishEvents(ctx, notificationEvents(notifications)); err != nil {
+		n.log.Errorf("cannot publish notification events: %v", err.Error())
+	}
+	n.publishUnreadCounts(ctx, n.withFeedUnread(ctx, counts))
+
+	return nil
+}
@@ -4,13 +4,23 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"github.com/Verce11o/yata-notifications/config"
 	"github.com/Verce11o/yata-notifications/internal/domain"
+	"github.com/Verce11o/yata-notifications/internal/events"
 	"github.com/Verce11o/yata-notifications/internal/lib/grpc_errors"
+	"github.com/Verce11o/yata-notifications/internal/metrics/prom"
 	"github.com/Verce11o/yata-notifications/internal/repository"
 	pb "github.com/Verce11o/yata-protos/gen/go/notifications"
+	"github.com/redis/go-redis/v9"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"google.golang.org/protobuf/types/known/timestamppb"
+	"sort"
+)
+
+const (
+	defaultNotificationsLimit = 30
+	maxNotificationsLimit     = 100
 )
 
 type NotificationsService struct {
@@ -18,33 +28,34 @@ type NotificationsService struct {
 	tracer trace.Tracer
 	repo   repository.Repository
 	redis  repository.RedisRepository
+	bus    events.Bus
+	cfg    config.Notifications
 }
 
-func NewNotificationsService(log *zap.SugaredLogger, tracer trace.Tracer, repo repository.Repository, redis repository.RedisRepository) *NotificationsService {
-	return &NotificationsService{log: log, tracer: tracer, repo: repo, redis: redis}
+func NewNotificationsService(log *zap.SugaredLogger, tracer trace.Tracer, repo repository.Repository, redis repository.RedisRepository, bus events.Bus, cfg config.Notifications) *NotificationsService {
+	return &NotificationsService{log: log, tracer: tracer, repo: repo, redis: redis, bus: bus, cfg: cfg}
 }
 
 func (n *NotificationsService) SubscribeToUser(ctx context.Context, request *pb.SubscribeToUserRequest) error {
 	ctx, span := n.tracer.Start(ctx, "notificationService.SubscribeToUser")
 	defer span.End()
 
-	subscription, err := n.repo.GetUserSubscription(ctx, request.GetUserId(), request.GetToUserId())
-	if !errors.Is(err, sql.ErrNoRows) && err != nil {
-		n.log.Errorf("cannot get user subscription by id %v", err.Error())
-		return err
-	}
-
-	if subscription != nil {
-		n.log.Infof("user already subscribed")
-		return grpc_errors.ErrSubAlreadyExists
-	}
-
 	if request.GetToUserId() == request.GetUserId() {
 		n.log.Errorf("user cannot subscribe to himself")
 		return grpc_errors.ErrInvalidUser
 	}
 
-	err = n.repo.SubscribeToUser(ctx, request.GetUserId(), request.GetToUserId())
+	level, types, err := subscriptionLevel(request.GetLevel(), request.GetTypes())
+	if err != nil {
+		return err
+	}
+
+	err = n.repo.SubscribeToUser(ctx, request.GetUserId(), request.GetToUserId(), level, types)
+
+	if errors.Is(err, grpc_errors.ErrSubAlreadyExists) {
+		n.log.Infof("user already subscribed")
+		return err
+	}
 
 	if err != nil {
 		n.log.Errorf("cannot subscribe user: %v", err.Error())
@@ -66,34 +77,45 @@ func (n *NotificationsService) UnSubscribeFromUser(ctx context.Context, request
 		return err
 	}
 
+	if n.cfg.FanOut.HybridThreshold > 0 {
+		if err := n.redis.DeleteNotificationsByUserID(ctx, request.GetUserId()); err != nil {
+			n.log.Errorf("cannot delete user notification cache: %v", err.Error())
+		}
+	}
+
 	return nil
 }
 
-func (n *NotificationsService) GetNotifications(ctx context.Context, userID string) ([]*pb.Notification, error) {
+func (n *NotificationsService) GetNotifications(ctx context.Context, userID string, cursor string, limit int) ([]*pb.Notification, string, error) {
 	ctx, span := n.tracer.Start(ctx, "notificationService.GetNotifications")
 	defer span.End()
 
-	cachedNotifications, err := n.redis.GetNotificationsByUserID(ctx, userID)
-	if err != nil {
+	limit = notificationsPageSize(limit)
+
+	cacheCursor := n.notificationsCacheCursor(ctx, userID, cursor)
+
+	cachedNotifications, nextCursor, err := n.redis.GetNotificationsByUserID(ctx, userID, cacheCursor, limit)
+	if err != nil && !errors.Is(err, redis.Nil) {
 		n.log.Errorf("cannot get cached notifications: %v", err.Error())
 	}
+	prom.CacheRequests.WithLabelValues("notifications", prom.CacheResult(err == nil)).Inc()
 
-	if cachedNotifications != nil {
-		return domainToNotificationPb(cachedNotifications), nil
+	if err == nil {
+		return domainToNotificationGroupPb(cachedNotifications), nextCursor, nil
 	}
 
-	notifications, err := n.repo.GetNotifications(ctx, userID)
+	groups, nextCursor, err := n.getNotificationGroups(ctx, userID, cursor, limit)
 
 	if err != nil {
 		n.log.Errorf("cannot get notifications: %v", err.Error())
-		return nil, err
+		return nil, "", err
 	}
 
-	if err := n.redis.SetNotificationsByUserID(ctx, userID, notifications); err != nil {
+	if err := n.redis.SetNotificationsByUserID(ctx, userID, cacheCursor, limit, groups, nextCursor); err != nil {
 		n.log.Errorf("cannot set notifications in redis: %v", err.Error())
 	}
 
-	return domainToNotificationPb(notifications), nil
+	return domainToNotificationGroupPb(groups), nextCursor, nil
 }
 
 func (n *NotificationsService) MarkNotificationAsRead(ctx context.Context, userID string, notificationID string) error {
@@ -110,13 +132,29 @@ func (n *NotificationsService) MarkNotificationAsRead(ctx context.Context, userI
 		return grpc_errors.ErrPermissionDenied
 	}
 
-	err = n.repo.MarkNotificationAsRead(ctx, userID, notificationID)
+	marked, err := n.repo.MarkNotificationAsRead(ctx, userID, notificationID)
 
 	if err != nil {
 		n.log.Errorf("cannot mark notification as read: %v", err)
 		return err
 	}
 
+	feedMarked, err := n.repo.MarkFeedEventsAsRead(ctx, userID, notificationID)
+
+	if err != nil {
+		n.log.Errorf("cannot mark feed events as read: %v", err)
+		return err
+	}
+
+	// The cached counter only covers personal notifications; feed events are counted on read.
+	if marked > 0 || feedMarked > 0 {
+		counts, err := n.redis.IncrUnreadCount(ctx, []string{userID}, -marked)
+		if err != nil {
+			n.log.Errorf("cannot decrement unread count: %v", err.Error())
+		}
+		n.publishUnreadCounts(ctx, n.withFeedUnread(ctx, counts))
+	}
+
 	err = n.redis.DeleteNotificationsByUserID(ctx, userID)
 
 	if err != nil {
@@ -133,11 +171,27 @@ func (n *NotificationsService) ReadAllNotifications(ctx context.Context, userID
 
 	err := n.repo.ReadAllNotifications(ctx, userID)
 
-	if err != nil {
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		n.log.Errorf("cannot read all notifications: %v", err.Error())
 		return err
 	}
 
+	feedRead, feedErr := n.repo.ReadAllFeedEvents(ctx, userID)
+
+	if feedErr != nil {
+		n.log.Errorf("cannot read all feed events: %v", feedErr.Error())
+		return feedErr
+	}
+
+	if err != nil && feedRead == 0 {
+		return err
+	}
+
+	if err := n.redis.SetUnreadCount(ctx, userID, 0); err != nil {
+		n.log.Errorf("cannot reset unread count: %v", err.Error())
+	}
+	n.publishUnreadCounts(ctx, map[string]int64{userID: 0})
+
 	err = n.redis.DeleteNotificationsByUserID(ctx, userID)
 	if err != nil {
 		n.log.Errorf("cannot delete notificaion in redis")
@@ -146,17 +200,55 @@ func (n *NotificationsService) ReadAllNotifications(ctx context.Context, userID
 	return nil
 }
 
-func (n *NotificationsService) GetUserSubscribers(ctx context.Context, userID string) ([]domain.Subscriber, error) {
+func (n *NotificationsService) GetUnreadCount(ctx context.Context, userID string) (int64, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationService.GetUnreadCount")
+	defer span.End()
+
+	count, err := n.personalUnreadCount(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return n.withFeedUnread(ctx, map[string]int64{userID: count})[userID], nil
+}
+
+func (n *NotificationsService) personalUnreadCount(ctx context.Context, userID string) (int64, error) {
+	count, err := n.redis.GetUnreadCount(ctx, userID)
+	prom.CacheRequests.WithLabelValues("unread_count", prom.CacheResult(err == nil)).Inc()
+	if err == nil {
+		return count, nil
+	}
+
+	if !errors.Is(err, redis.Nil) {
+		n.log.Errorf("cannot get cached unread count: %v", err.Error())
+	}
+
+	count, err = n.repo.CountUnreadNotifications(ctx, userID)
+	if err != nil {
+		n.log.Errorf("cannot count unread notifications: %v", err.Error())
+		return 0, err
+	}
+
+	cached, err := n.redis.RebuildUnreadCount(ctx, userID, count)
+	if err != nil {
+		n.log.Errorf("cannot set unread count in redis: %v", err.Error())
+		return count, nil
+	}
+
+	return cached, nil
+}
+
+func (n *NotificationsService) GetUserSubscribers(ctx context.Context, userID string, cursor string) ([]*pb.Subscriber, string, error) {
 	ctx, span := n.tracer.Start(ctx, "notificationService.GetUserSubscribers")
 	defer span.End()
 
-	subs, err := n.repo.GetUserSubscribers(ctx, userID)
+	subscribers, cursor, err := n.repo.GetUserSubscribers(ctx, userID, cursor)
 	if err != nil {
 		n.log.Errorf("cannot get user subscribers: %v", err.Error())
-		return nil, err
+		return nil, "", err
 	}
 
-	return subs, nil
+	return domainToSubscriberPb(subscribers), cursor, nil
 }
 
 func (n *NotificationsService) GetUserSubscriptions(ctx context.Context, userID string, cursor string) ([]*pb.Subscriber, string, error) {
@@ -176,22 +268,56 @@ func (n *NotificationsService) BatchAddNotification(ctx context.Context, subscri
 	ctx, span := n.tracer.Start(ctx, "notificationService.BatchAddNotification")
 	defer span.End()
 
-	err := n.repo.BatchAddNotification(ctx, subscribers, notification)
+	sorted := make([]domain.Subscriber, len(subscribers))
+	copy(sorted, subscribers)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].UserID < sorted[j].UserID
+	})
+
+	return n.notify(ctx, notification, n.fanOutTo(slicePages(sorted)))
+}
+
+func (n *NotificationsService) NotifySubscribers(ctx context.Context, notification domain.IncomingNewNotification) error {
+	ctx, span := n.tracer.Start(ctx, "notificationService.NotifySubscribers")
+	defer span.End()
+
+	senderID := notification.SenderID.String()
 
+	hybrid, err := n.isHybridAuthor(ctx, senderID)
 	if err != nil {
-		n.log.Errorf("cannot add new notification: %v", err.Error())
+		n.log.Errorf("cannot count user subscribers: %v", err.Error())
 		return err
 	}
 
-	for _, sub := range subscribers {
-		if err := n.redis.DeleteNotificationsByUserID(ctx, sub.UserID); err != nil {
-			n.log.Errorf("cannot delete user notification cache: %v", err.Error())
-			return err
-		}
+	if hybrid {
+		return n.notify(ctx, notification, n.storeAuthorFeedEvent)
 	}
 
-	return nil
+	return n.notify(ctx, notification, n.fanOutTo(func(ctx context.Context, afterUserID string, limit int) ([]domain.Subscriber, error) {
+		return n.repo.GetUserSubscribersPage(ctx, senderID, afterUserID, limit)
+	}))
+}
+
+func (n *NotificationsService) markEventProcessed(ctx context.Context, eventID string) {
+	if eventID == "" {
+		return
+	}
+
+	if err := n.redis.MarkEventProcessed(ctx, eventID, n.cfg.Dedup.TTL); err != nil {
+		n.log.Errorf("cannot mark event as processed: %v", err.Error())
+	}
+}
+
+func notificationsPageSize(limit int) int {
+	if limit <= 0 {
+		return defaultNotificationsLimit
+	}
+
+	if limit > maxNotificationsLimit {
+		return maxNotificationsLimit
+	}
 
+	return limit
 }
 
 func domainToNotificationPb(notifications []domain.Notification) []*pb.Notification {
@@ -205,6 +331,9 @@ func domainToNotificationPb(notifications []domain.Notification) []*pb.Notificat
 			Read:           notification.Read,
 			CreatedAt:      timestamppb.New(notification.CreatedAt),
 			Type:           notification.Type,
+			EntityKind:     notification.EntityKind,
+			EntityId:       notification.EntityID,
+			Metadata:       notification.Metadata.String(),
 		})
 	}
 	return result
@@ -218,6 +347,8 @@ func domainToSubscriberPb(subscribers []domain.Subscriber) []*pb.Subscriber {
 			UserId:    subscriber.UserID,
 			ToUserId:  subscriber.ToUserID,
 			CreatedAt: timestamppb.New(subscriber.CreatedAt),
+			Level:     string(subscriber.Level),
+			Types:     subscriber.Types,
 		})
 	}
 	return result
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"github.com/Verce11o/yata-notifications/config"
+	"github.com/Verce11o/yata-notifications/internal/domain"
+	"github.com/Verce11o/yata-notifications/internal/repository"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	defaultOutboxInterval  = time.Second
+	defaultOutboxBatchSize = 100
+	defaultOutboxLease     = 30 * time.Second
+)
+
+type OutboxPublisher interface {
+	Publish(ctx context.Context, messages []domain.OutboxMessage) ([]uuid.UUID, error)
+}
+
+type OutboxRelay struct {
+	log       *zap.SugaredLogger
+	tracer    trace.Tracer
+	repo      repository.Outbox
+	publisher OutboxPublisher
+	cfg       config.Outbox
+}
+
+func NewOutboxRelay(log *zap.SugaredLogger, tracer trace.Tracer, repo repository.Outbox, publisher OutboxPublisher, cfg config.Outbox) *OutboxRelay {
+	return &OutboxRelay{log: log, tracer: tracer, repo: repo, publisher: publisher, cfg: cfg}
+}
+
+func (o *OutboxRelay) Run(ctx context.Context) {
+	interval := o.cfg.Interval
+	if interval <= 0 {
+		interval = defaultOutboxInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			o.relayPending(ctx)
+		}
+	}
+}
+
+func (o *OutboxRelay) relayPending(ctx context.Context) {
+	ctx, span := o.tracer.Start(ctx, "outboxRelay.relayPending")
+	defer span.End()
+
+	batchSize := o.cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultOutboxBatchSize
+	}
+
+	lease := o.cfg.Lease
+	if lease <= 0 {
+		lease = defaultOutboxLease
+	}
+
+	for {
+		messages, err := o.repo.ClaimOutboxMessages(ctx, time.Now(), lease, batchSize)
+		if err != nil {
+			o.log.Errorf("cannot claim outbox messages: %v", err.Error())
+			return
+		}
+
+		if len(messages) == 0 {
+			return
+		}
+
+		published, err := o.publisher.Publish(ctx, messages)
+		if err != nil {
+			o.log.Errorf("cannot publish outbox messages: %v", err.Error())
+		}
+
+		if err := o.repo.DeleteOutboxMessages(ctx, published); err != nil {
+			o.log.Errorf("cannot delete published outbox messages: %v", err.Error())
+			return
+		}
+
+		// Unconfirmed messages stay claimed until their lease runs out.
+		if len(published) < len(messages) || len(messages) < batchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/Verce11o/yata-notifications/internal/domain"
+	"github.com/Verce11o/yata-notifications/internal/lib/grpc_errors"
+	pb "github.com/Verce11o/yata-protos/gen/go/notifications"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
+)
+
+func (n *NotificationsService) GetPreferences(ctx context.Context, userID string) (*pb.NotificationPreferences, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationService.GetPreferences")
+	defer span.End()
+
+	preferences, err := n.repo.GetPreferences(ctx, userID)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return domainToPreferencesPb(domain.Preferences{UserID: userID, Timezone: defaultTimezone}), nil
+	}
+
+	if err != nil {
+		n.log.Errorf("cannot get preferences: %v", err.Error())
+		return nil, err
+	}
+
+	return domainToPreferencesPb(preferences), nil
+}
+
+func (n *NotificationsService) UpdatePreferences(ctx context.Context, request *pb.UpdateNotificationPreferencesRequest) (*pb.NotificationPreferences, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationService.UpdatePreferences")
+	defer span.End()
+
+	for _, senderID := range request.GetMutedSenderIds() {
+		if err := validateSender(request.GetUserId(), senderID); err != nil {
+			return nil, err
+		}
+	}
+
+	timezone := request.GetTimezone()
+	if timezone == "" {
+		timezone = defaultTimezone
+	}
+
+	if err := validateQuietHours(request.GetQuietHoursStart(), request.GetQuietHoursEnd(), timezone); err != nil {
+		return nil, err
+	}
+
+	preferences := domain.Preferences{
+		UserID:          request.GetUserId(),
+		DisabledTypes:   request.GetDisabledTypes(),
+		MutedSenders:    request.GetMutedSenderIds(),
+		QuietHoursStart: request.GetQuietHoursStart(),
+		QuietHoursEnd:   request.GetQuietHoursEnd(),
+		Timezone:        timezone,
+	}
+
+	if request.GetMutedUntil() != nil {
+		mutedUntil := request.GetMutedUntil().AsTime()
+		preferences.MutedUntil = &mutedUntil
+	}
+
+	preferences, err := n.repo.UpdatePreferences(ctx, preferences)
+
+	if err != nil {
+		n.log.Errorf("cannot update preferences: %v", err.Error())
+		return nil, err
+	}
+
+	return domainToPreferencesPb(preferences), nil
+}
+
+func (n *NotificationsService) DeletePreferences(ctx context.Context, userID string) error {
+	ctx, span := n.tracer.Start(ctx, "notificationService.DeletePreferences")
+	defer span.End()
+
+	err := n.repo.DeletePreferences(ctx, userID)
+
+	if err != nil {
+		n.log.Errorf("cannot delete preferences: %v", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (n *NotificationsService) SetNotificationTypeEnabled(ctx context.Context, userID string, notificationType string, enabled bool) error {
+	ctx, span := n.tracer.Start(ctx, "notificationService.SetNotificationTypeEnabled")
+	defer span.End()
+
+	if notificationType == "" {
+		return grpc_errors.ErrInvalidNotificationType
+	}
+
+	err := n.repo.SetNotificationTypeEnabled(ctx, userID, notificationType, enabled)
+
+	if err != nil {
+		n.log.Errorf("cannot set notification type enabled: %v", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (n *NotificationsService) MuteSender(ctx context.Context, userID string, senderID string) error {
+	ctx, span := n.tracer.Start(ctx, "notificationService.MuteSender")
+	defer span.End()
+
+	if err := validateSender(userID, senderID); err != nil {
+		return err
+	}
+
+	err := n.repo.MuteSender(ctx, userID, senderID)
+
+	if err != nil {
+		n.log.Errorf("cannot mute sender: %v", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (n *NotificationsService) UnmuteSender(ctx context.Context, userID string, senderID string) error {
+	ctx, span := n.tracer.Start(ctx, "notificationService.UnmuteSender")
+	defer span.End()
+
+	if err := validateSender(userID, senderID); err != nil {
+		return err
+	}
+
+	err := n.repo.UnmuteSender(ctx, userID, senderID)
+
+	if err != nil {
+		n.log.Errorf("cannot unmute sender: %v", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (n *NotificationsService) MuteAll(ctx context.Context, userID string, until *time.Time) error {
+	ctx, span := n.tracer.Start(ctx, "notificationService.MuteAll")
+	defer span.End()
+
+	err := n.repo.MuteAll(ctx, userID, until)
+
+	if err != nil {
+		n.log.Errorf("cannot mute all notifications: %v", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (n *NotificationsService) filterMutedRecipients(ctx context.Context, subscribers []domain.Subscriber, notification domain.IncomingNewNotification) ([]domain.Subscriber, error) {
+	if len(subscribers) == 0 {
+		return subscribers, nil
+	}
+
+	userIDs := make([]string, 0, len(subscribers))
+	for _, sub := range subscribers {
+		userIDs = append(userIDs, sub.UserID)
+	}
+
+	muted, err := n.repo.GetMutedRecipients(ctx, userIDs, notification.SenderID.String(), notification.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(muted) == 0 {
+		return subscribers, nil
+	}
+
+	mutedSet := make(map[string]struct{}, len(muted))
+	for _, userID := range muted {
+		mutedSet[userID] = struct{}{}
+	}
+
+	result := make([]domain.Subscriber, 0, len(subscribers)-len(muted))
+	for _, sub := range subscribers {
+		if _, ok := mutedSet[sub.UserID]; !ok {
+			result = append(result, sub)
+		}
+	}
+
+	return result, nil
+}
+
+func validateSender(userID string, senderID string) error {
+	if _, err := uuid.Parse(senderID); err != nil || senderID == userID {
+		return grpc_errors.ErrInvalidUser
+	}
+	return nil
+}
+
+func domainToPreferencesPb(preferences domain.Preferences) *pb.NotificationPreferences {
+	result := &pb.NotificationPreferences{
+		UserId:          preferences.UserID,
+		DisabledTypes:   preferences.DisabledTypes,
+		MutedSenderIds:  preferences.MutedSenders,
+		QuietHoursStart: preferences.QuietHoursStart,
+		QuietHoursEnd:   preferences.QuietHoursEnd,
+		Timezone:        preferences.Timezone,
+	}
+
+	if preferences.MutedUntil != nil {
+		result.MutedUntil = timestamppb.New(*preferences.MutedUntil)
+	}
+
+	if !preferences.UpdatedAt.IsZero() {
+		result.UpdatedAt = timestamppb.New(preferences.UpdatedAt)
+	}
+
+	return result
+}
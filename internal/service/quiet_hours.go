@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"github.com/Verce11o/yata-notifications/internal/domain"
+	"github.com/Verce11o/yata-notifications/internal/lib/grpc_errors"
+	"sync"
+	"time"
+)
+
+const (
+	quietHoursLayout          = "15:04"
+	defaultTimezone           = "UTC"
+	defaultReleaseInterval    = 30 * time.Second
+	deferredEventsReleaseSize = 500
+)
+
+var locations sync.Map
+
+func (n *NotificationsService) SetQuietHours(ctx context.Context, userID string, start string, end string, timezone string) error {
+	ctx, span := n.tracer.Start(ctx, "notificationService.SetQuietHours")
+	defer span.End()
+
+	if timezone == "" {
+		timezone = defaultTimezone
+	}
+
+	if err := validateQuietHours(start, end, timezone); err != nil {
+		return err
+	}
+
+	err := n.repo.SetQuietHours(ctx, userID, start, end, timezone)
+
+	if err != nil {
+		n.log.Errorf("cannot set quiet hours: %v", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (n *NotificationsService) RunDeferredDelivery(ctx context.Context) {
+	interval := n.cfg.QuietHours.ReleaseInterval
+	if interval <= 0 {
+		interval = defaultReleaseInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n.releaseDeferredEvents(ctx)
+		}
+	}
+}
+
+func (n *NotificationsService) releaseDeferredEvents(ctx context.Context) {
+	ctx, span := n.tracer.Start(ctx, "notificationService.releaseDeferredEvents")
+	defer span.End()
+
+	for {
+		events, popped, err := n.redis.PopDueEvents(ctx, time.Now(), deferredEventsReleaseSize)
+		if err != nil {
+			n.log.Errorf("cannot pop deferred events: %v", err.Error())
+			return
+		}
+
+		if skipped := popped - len(events); skipped > 0 {
+			n.log.Errorf("cannot decode %d deferred events, dropping them", skipped)
+		}
+
+		events = n.withCurrentUnreadCounts(ctx, events)
+
+		if err := n.bus.Publish(ctx, events...); err != nil {
+			n.log.Errorf("cannot publish deferred events: %v", err.Error())
+			n.redeferEvents(ctx, events)
+			return
+		}
+
+		if popped < deferredEventsReleaseSize {
+			return
+		}
+	}
+}
+
+func (n *NotificationsService) withCurrentUnreadCounts(ctx context.Context, events []domain.Event) []domain.Event {
+	result := make([]domain.Event, 0, len(events))
+
+	for _, event := range events {
+		if event.Type == domain.EventUnreadCountChanged && event.UnreadCount == nil {
+			count, err := n.GetUnreadCount(ctx, event.UserID)
+			if err != nil {
+				continue
+			}
+			event.UnreadCount = &count
+		}
+		result = append(result, event)
+	}
+
+	return result
+}
+
+func (n *NotificationsService) redeferEvents(ctx context.Context, events []domain.Event) {
+	now := time.Now()
+	deferred := make([]domain.DeferredEvent, 0, len(events))
+
+	for _, event := range events {
+		deferred = append(deferred, deferredEvent(event, now))
+	}
+
+	if err := n.redis.DeferEvents(context.WithoutCancel(ctx), deferred); err != nil {
+		n.log.Errorf("cannot put back deferred events: %v", err.Error())
+	}
+}
+
+func (n *NotificationsService) publishEvents(ctx context.Context, events []domain.Event) error {
+	userIDs := make([]string, 0, len(events))
+	for _, event := range events {
+		userIDs = append(userIDs, event.UserID)
+	}
+
+	preferences, err := n.repo.GetQuietHoursPreferences(ctx, userIDs)
+	if err != nil {
+		n.log.Errorf("cannot get quiet hours: %v", err.Error())
+		return n.bus.Publish(ctx, events...)
+	}
+
+	now := time.Now()
+	releaseAt := make(map[string]time.Time, len(preferences))
+
+	for _, p := range preferences {
+		if end, ok := quietHoursEnd(p, now); ok {
+			releaseAt[p.UserID] = end
+		}
+	}
+
+	if len(releaseAt) == 0 {
+		return n.bus.Publish(ctx, events...)
+	}
+
+	immediate := make([]domain.Event, 0, len(events))
+	deferred := make([]domain.DeferredEvent, 0, len(releaseAt))
+
+	for _, event := range events {
+		if end, ok := releaseAt[event.UserID]; ok {
+			deferred = append(deferred, deferredEvent(event, end))
+			continue
+		}
+		immediate = append(immediate, event)
+	}
+
+	if err := n.redis.DeferEvents(ctx, deferred); err != nil {
+		n.log.Errorf("cannot defer events: %v", err.Error())
+		immediate = events
+	}
+
+	return n.bus.Publish(ctx, immediate...)
+}
+
+// deferredEvent drops unread counts: they collapse into one event per user and are read on release.
+func deferredEvent(event domain.Event, releaseAt time.Time) domain.DeferredEvent {
+	if event.Type == domain.EventUnreadCountChanged {
+		event.UnreadCount = nil
+	}
+	return domain.DeferredEvent{Event: event, ReleaseAt: releaseAt}
+}
+
+// quietHoursEnd treats a window whose start is after its end as spanning midnight.
+func quietHoursEnd(preferences domain.Preferences, now time.Time) (time.Time, bool) {
+	start, err := time.Parse(quietHoursLayout, preferences.QuietHoursStart)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	end, err := time.Parse(quietHoursLayout, preferences.QuietHoursEnd)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	loc, err := loadLocation(preferences.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	local := now.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+
+	var inside bool
+	if startMinute < endMinute {
+		inside = minute >= startMinute && minute < endMinute
+	} else {
+		inside = minute >= startMinute || minute < endMinute
+	}
+
+	if !inside {
+		return time.Time{}, false
+	}
+
+	releaseAt := time.Date(local.Year(), local.Month(), local.Day(), end.Hour(), end.Minute(), 0, 0, loc)
+	if !releaseAt.After(local) {
+		releaseAt = releaseAt.AddDate(0, 0, 1)
+	}
+
+	return releaseAt, true
+}
+
+func loadLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+func validateQuietHours(start string, end string, timezone string) error {
+	if start == "" && end == "" {
+		return nil
+	}
+
+	startTime, err := time.Parse(quietHoursLayout, start)
+	if err != nil {
+		return grpc_errors.ErrInvalidQuietHours
+	}
+
+	endTime, err := time.Parse(quietHoursLayout, end)
+	if err != nil || startTime.Equal(endTime) {
+		return grpc_errors.ErrInvalidQuietHours
+	}
+
+	if _, err := loadLocation(timezone); err != nil {
+		return grpc_errors.ErrInvalidQuietHours
+	}
+
+	return nil
+}
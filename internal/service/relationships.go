@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"github.com/Verce11o/yata-notifications/internal/lib/grpc_errors"
+	pb "github.com/Verce11o/yata-protos/gen/go/notifications"
+	"github.com/google/uuid"
+)
+
+func (n *NotificationsService) GetRelationships(ctx context.Context, userID string, targetUserIDs []string) ([]*pb.Relationship, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationService.GetRelationships")
+	defer span.End()
+
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, grpc_errors.ErrInvalidUser
+	}
+
+	if err := validateUserBatch(targetUserIDs); err != nil {
+		return nil, err
+	}
+
+	if len(targetUserIDs) == 0 {
+		return []*pb.Relationship{}, nil
+	}
+
+	relationships, err := n.repo.GetRelationships(ctx, userID, targetUserIDs)
+	if err != nil {
+		n.log.Errorf("cannot get relationships: %v", err.Error())
+		return nil, err
+	}
+
+	result := make([]*pb.Relationship, 0, len(relationships))
+
+	// Rows follow the order of targetUserIDs; keep the IDs in the form the caller sent.
+	for i, relationship := range relationships {
+		result = append(result, &pb.Relationship{
+			UserId:     targetUserIDs[i],
+			Following:  relationship.Following,
+			FollowedBy: relationship.FollowedBy,
+			Mutual:     relationship.Mutual(),
+		})
+	}
+
+	return result, nil
+}
+
+func (n *NotificationsService) GetMutualFollowers(ctx context.Context, userID string, cursor string) ([]*pb.Subscriber, string, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationService.GetMutualFollowers")
+	defer span.End()
+
+	subscribers, cursor, err := n.repo.GetMutualFollowers(ctx, userID, cursor)
+	if err != nil {
+		n.log.Errorf("cannot get mutual followers: %v", err.Error())
+		return nil, "", err
+	}
+
+	return domainToSubscriberPb(subscribers), cursor, nil
+}
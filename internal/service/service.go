@@ -4,15 +4,34 @@ import (
 	"context"
 	"github.com/Verce11o/yata-notifications/internal/domain"
 	pb "github.com/Verce11o/yata-protos/gen/go/notifications"
+	"time"
 )
 
 type Notifications interface {
 	SubscribeToUser(ctx context.Context, request *pb.SubscribeToUserRequest) error
 	UnSubscribeFromUser(ctx context.Context, request *pb.UnSubscribeFromUserRequest) error
-	GetUserSubscribers(ctx context.Context, userID string) ([]domain.Subscriber, error)
+	UpdateSubscriptionLevel(ctx context.Context, request *pb.UpdateSubscriptionLevelRequest) error
+	GetUserSubscribers(ctx context.Context, userID string, cursor string) ([]*pb.Subscriber, string, error)
 	GetUserSubscriptions(ctx context.Context, userID string, cursor string) ([]*pb.Subscriber, string, error)
+	GetSubscriptionCounts(ctx context.Context, userIDs []string) ([]*pb.SubscriptionCounts, error)
+	GetRelationships(ctx context.Context, userID string, targetUserIDs []string) ([]*pb.Relationship, error)
+	GetMutualFollowers(ctx context.Context, userID string, cursor string) ([]*pb.Subscriber, string, error)
 	BatchAddNotification(ctx context.Context, subscribers []domain.Subscriber, notification domain.IncomingNewNotification) error
-	GetNotifications(ctx context.Context, userID string) ([]*pb.Notification, error)
+	NotifySubscribers(ctx context.Context, notification domain.IncomingNewNotification) error
+	GetNotifications(ctx context.Context, userID string, cursor string, limit int) ([]*pb.Notification, string, error)
 	MarkNotificationAsRead(ctx context.Context, userID string, notificationID string) error
 	ReadAllNotifications(ctx context.Context, userID string) error
+	GetUnreadCount(ctx context.Context, userID string) (int64, error)
+	SubscribeEvents(ctx context.Context, userID string, lastEventID string) (<-chan domain.Event, error)
+	StreamNotifications(ctx context.Context, userID string, send func(notification *pb.Notification) error) error
+	GetPreferences(ctx context.Context, userID string) (*pb.NotificationPreferences, error)
+	UpdatePreferences(ctx context.Context, request *pb.UpdateNotificationPreferencesRequest) (*pb.NotificationPreferences, error)
+	DeletePreferences(ctx context.Context, userID string) error
+	SetNotificationTypeEnabled(ctx context.Context, userID string, notificationType string, enabled bool) error
+	MuteSender(ctx context.Context, userID string, senderID string) error
+	UnmuteSender(ctx context.Context, userID string, senderID string) error
+	MuteAll(ctx context.Context, userID string, until *time.Time) error
+	SetQuietHours(ctx context.Context, userID string, start string, end string, timezone string) error
+	SubscribeToDigest(ctx context.Context, userID string, frequency string, email string) error
+	UnsubscribeFromDigest(ctx context.Context, userID string) error
 }
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"github.com/Verce11o/yata-notifications/config"
+	"github.com/Verce11o/yata-notifications/internal/lib/grpc_errors"
+	"github.com/Verce11o/yata-notifications/internal/repository"
+	pb "github.com/Verce11o/yata-protos/gen/go/notifications"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	maxUserBatch = 100
+
+	defaultCountsReconcileInterval  = time.Hour
+	defaultCountsReconcileBatchSize = 1000
+)
+
+func (n *NotificationsService) GetSubscriptionCounts(ctx context.Context, userIDs []string) ([]*pb.SubscriptionCounts, error) {
+	ctx, span := n.tracer.Start(ctx, "notificationService.GetSubscriptionCounts")
+	defer span.End()
+
+	if err := validateUserBatch(userIDs); err != nil {
+		return nil, err
+	}
+
+	counts, err := n.repo.GetSubscriptionCounts(ctx, userIDs)
+	if err != nil {
+		n.log.Errorf("cannot get subscription counts: %v", err.Error())
+		return nil, err
+	}
+
+	result := make([]*pb.SubscriptionCounts, 0, len(userIDs))
+	byUser := make(map[string]*pb.SubscriptionCounts, len(counts))
+
+	for _, c := range counts {
+		byUser[c.UserID] = &pb.SubscriptionCounts{UserId: c.UserID, Followers: c.Followers, Following: c.Following}
+	}
+
+	// Postgres returns canonical lower-case IDs, while callers may send any form uuid.Parse accepts.
+	for _, userID := range userIDs {
+		c, ok := byUser[uuid.MustParse(userID).String()]
+		if !ok {
+			c = &pb.SubscriptionCounts{}
+		}
+		result = append(result, &pb.SubscriptionCounts{UserId: userID, Followers: c.Followers, Following: c.Following})
+	}
+
+	return result, nil
+}
+
+func validateUserBatch(userIDs []string) error {
+	if len(userIDs) > maxUserBatch {
+		return grpc_errors.ErrBatchTooLarge
+	}
+
+	for _, userID := range userIDs {
+		if _, err := uuid.Parse(userID); err != nil {
+			return grpc_errors.ErrInvalidUser
+		}
+	}
+
+	return nil
+}
+
+type CountsReconciler struct {
+	log    *zap.SugaredLogger
+	tracer trace.Tracer
+	repo   repository.Subscribe
+	cfg    config.Counts
+}
+
+func NewCountsReconciler(log *zap.SugaredLogger, tracer trace.Tracer, repo repository.Subscribe, cfg config.Counts) *CountsReconciler {
+	return &CountsReconciler{log: log, tracer: tracer, repo: repo, cfg: cfg}
+}
+
+func (r *CountsReconciler) Run(ctx context.Context) {
+	interval := r.cfg.ReconcileInterval
+	if interval <= 0 {
+		interval = defaultCountsReconcileInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.reconcile(ctx)
+		}
+	}
+}
+
+func (r *CountsReconciler) reconcile(ctx context.Context) {
+	ctx, span := r.tracer.Start(ctx, "countsReconciler.reconcile")
+	defer span.End()
+
+	batchSize := r.cfg.ReconcileBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultCountsReconcileBatchSize
+	}
+
+	var afterUserID string
+	var total int64
+
+	for {
+		lastUserID, fixed, err := r.repo.ReconcileSubscriptionCounts(ctx, afterUserID, batchSize)
+		if err != nil {
+			r.log.Errorf("cannot reconcile subscription counts: %v", err.Error())
+			return
+		}
+
+		total += fixed
+
+		if lastUserID == "" {
+			break
+		}
+		afterUserID = lastUserID
+	}
+
+	if total > 0 {
+		r.log.Infof("fixed %d drifted subscription counts", total)
+	}
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"github.com/Verce11o/yata-notifications/internal/domain"
+	"github.com/Verce11o/yata-notifications/internal/lib/grpc_errors"
+	pb "github.com/Verce11o/yata-protos/gen/go/notifications"
+)
+
+func (n *NotificationsService) UpdateSubscriptionLevel(ctx context.Context, request *pb.UpdateSubscriptionLevelRequest) error {
+	ctx, span := n.tracer.Start(ctx, "notificationService.UpdateSubscriptionLevel")
+	defer span.End()
+
+	level, types, err := subscriptionLevel(request.GetLevel(), request.GetTypes())
+	if err != nil {
+		return err
+	}
+
+	err = n.repo.UpdateSubscriptionLevel(ctx, request.GetUserId(), request.GetToUserId(), level, types)
+
+	if err != nil {
+		n.log.Errorf("cannot update subscription level: %v", err.Error())
+		return err
+	}
+
+	if n.cfg.FanOut.HybridThreshold > 0 {
+		if err := n.redis.DeleteNotificationsByUserID(ctx, request.GetUserId()); err != nil {
+			n.log.Errorf("cannot delete user notification cache: %v", err.Error())
+		}
+	}
+
+	return nil
+}
+
+func subscriptionLevel(level string, types []string) (domain.SubscriptionLevel, []string, error) {
+	switch domain.SubscriptionLevel(level) {
+	case "", domain.SubscriptionLevelAll:
+		return domain.SubscriptionLevelAll, []string{}, nil
+	case domain.SubscriptionLevelNone:
+		return domain.SubscriptionLevelNone, []string{}, nil
+	case domain.SubscriptionLevelTypes:
+		if len(types) == 0 {
+			return "", nil, grpc_errors.ErrInvalidSubscriptionLevel
+		}
+
+		for _, notificationType := range types {
+			if notificationType == "" {
+				return "", nil, grpc_errors.ErrInvalidNotificationType
+			}
+		}
+
+		return domain.SubscriptionLevelTypes, types, nil
+	}
+
+	return "", nil, grpc_errors.ErrInvalidSubscriptionLevel
+}
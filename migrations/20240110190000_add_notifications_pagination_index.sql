@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS notifications_to_user_id_created_at_idx
+    ON notifications (to_user_id, created_at DESC, notification_id DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS notifications_to_user_id_created_at_idx;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS entity_kind VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS entity_id   VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS metadata    JSONB        NOT NULL DEFAULT '{}'::jsonb;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE notifications
+    DROP COLUMN IF EXISTS entity_kind,
+    DROP COLUMN IF EXISTS entity_id,
+    DROP COLUMN IF EXISTS metadata;
+-- +goose StatementEnd
@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS group_key VARCHAR(1024);
+
+CREATE INDEX IF NOT EXISTS notifications_to_user_id_group_key_idx
+    ON notifications (to_user_id, group_key) WHERE group_key IS NOT NULL;
+
+-- A group is positioned by its first notification, so new members don't move it between pages.
+-- Notifications without a group key form a group of their own, keyed by their ID.
+CREATE TABLE IF NOT EXISTS notification_groups
+(
+    user_id               UUID                     NOT NULL,
+    group_key             VARCHAR(1024)            NOT NULL,
+    first_created_at      TIMESTAMP WITH TIME ZONE NOT NULL,
+    first_notification_id UUID                     NOT NULL,
+    PRIMARY KEY (user_id, group_key)
+);
+
+CREATE INDEX IF NOT EXISTS notification_groups_user_id_position_idx
+    ON notification_groups (user_id, first_created_at DESC, first_notification_id DESC);
+
+INSERT INTO notification_groups (user_id, group_key, first_created_at, first_notification_id)
+SELECT DISTINCT ON (to_user_id, COALESCE(group_key, notification_id::text))
+    to_user_id, COALESCE(group_key, notification_id::text), created_at, notification_id
+FROM notifications
+ORDER BY to_user_id, COALESCE(group_key, notification_id::text), created_at, notification_id
+ON CONFLICT (user_id, group_key) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS notification_groups;
+
+DROP INDEX IF EXISTS notifications_to_user_id_group_key_idx;
+
+ALTER TABLE notifications DROP COLUMN IF EXISTS group_key;
+-- +goose StatementEnd
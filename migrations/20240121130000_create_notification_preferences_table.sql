@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS notification_preferences
+(
+    user_id        UUID PRIMARY KEY,
+    disabled_types TEXT[]                   NOT NULL DEFAULT '{}',
+    muted_senders  UUID[]                   NOT NULL DEFAULT '{}',
+    muted_until    TIMESTAMP WITH TIME ZONE,
+    updated_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS notification_preferences;
+-- +goose StatementEnd
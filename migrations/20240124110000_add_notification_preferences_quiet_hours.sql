@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE notification_preferences
+    ADD COLUMN IF NOT EXISTS quiet_hours_start VARCHAR(5)   NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS quiet_hours_end   VARCHAR(5)   NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS timezone          VARCHAR(255) NOT NULL DEFAULT 'UTC';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE notification_preferences
+    DROP COLUMN IF EXISTS quiet_hours_start,
+    DROP COLUMN IF EXISTS quiet_hours_end,
+    DROP COLUMN IF EXISTS timezone;
+-- +goose StatementEnd
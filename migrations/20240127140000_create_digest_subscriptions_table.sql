@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS digest_subscriptions
+(
+    user_id      UUID PRIMARY KEY,
+    email        VARCHAR(255)             NOT NULL,
+    frequency    VARCHAR(16)              NOT NULL,
+    last_sent_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS digest_subscriptions_frequency_last_sent_at_idx
+    ON digest_subscriptions (frequency, last_sent_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS digest_subscriptions;
+-- +goose StatementEnd
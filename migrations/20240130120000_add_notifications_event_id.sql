@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS event_id VARCHAR(255);
+
+ALTER TABLE notifications
+    ADD CONSTRAINT notifications_event_id_to_user_id_key UNIQUE (event_id, to_user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_event_id_to_user_id_key;
+
+ALTER TABLE notifications DROP COLUMN IF EXISTS event_id;
+-- +goose StatementEnd
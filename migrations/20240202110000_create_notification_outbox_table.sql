@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS notification_outbox
+(
+    id           UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    event_type   VARCHAR(255)             NOT NULL,
+    payload      JSONB                    NOT NULL,
+    attempts     INTEGER                  NOT NULL DEFAULT 0,
+    locked_until TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT '-infinity',
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS notification_outbox_locked_until_created_at_idx
+    ON notification_outbox (locked_until, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS notification_outbox;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS fanout_checkpoints
+(
+    event_id          VARCHAR(255) PRIMARY KEY,
+    last_recipient_id UUID                     NOT NULL,
+    updated_at        TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS fanout_checkpoints;
+-- +goose StatementEnd
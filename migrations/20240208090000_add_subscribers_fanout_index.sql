@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS subscribers_to_user_id_user_id_idx
+    ON subscribers (to_user_id, user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS subscribers_to_user_id_user_id_idx;
+-- +goose StatementEnd
@@ -0,0 +1,53 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS author_feed
+(
+    feed_event_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    author_id     UUID                     NOT NULL,
+    type          VARCHAR(255)             NOT NULL,
+    entity_kind   VARCHAR(255)             NOT NULL DEFAULT '',
+    entity_id     VARCHAR(255)             NOT NULL DEFAULT '',
+    metadata      JSONB                    NOT NULL DEFAULT '{}'::jsonb,
+    group_key     VARCHAR(1024),
+    event_id      VARCHAR(255) UNIQUE,
+    created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS author_feed_author_id_created_at_idx
+    ON author_feed (author_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS author_feed_group_key_idx
+    ON author_feed (group_key) WHERE group_key IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS author_feed_groups
+(
+    author_id           UUID                     NOT NULL,
+    group_key           VARCHAR(1024)            NOT NULL,
+    first_created_at    TIMESTAMP WITH TIME ZONE NOT NULL,
+    first_feed_event_id UUID                     NOT NULL,
+    PRIMARY KEY (author_id, group_key)
+);
+
+CREATE INDEX IF NOT EXISTS author_feed_groups_author_id_position_idx
+    ON author_feed_groups (author_id, first_created_at DESC, first_feed_event_id DESC);
+
+CREATE INDEX IF NOT EXISTS author_feed_groups_group_key_idx
+    ON author_feed_groups (group_key);
+
+CREATE TABLE IF NOT EXISTS author_feed_reads
+(
+    user_id       UUID                     NOT NULL,
+    feed_event_id UUID                     NOT NULL REFERENCES author_feed (feed_event_id) ON DELETE CASCADE,
+    read_at       TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, feed_event_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS author_feed_groups;
+
+DROP TABLE IF EXISTS author_feed_reads;
+
+DROP TABLE IF EXISTS author_feed;
+-- +goose StatementEnd
@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+DELETE FROM subscribers a
+    USING subscribers b
+WHERE a.user_id = b.user_id
+  AND a.to_user_id = b.to_user_id
+  AND a.id > b.id;
+
+-- Dropping the SERIAL id also drops the indexes built on it.
+ALTER TABLE subscribers DROP COLUMN id;
+ALTER TABLE subscribers ADD COLUMN id UUID PRIMARY KEY DEFAULT uuid_generate_v4();
+
+ALTER TABLE subscribers
+    ADD CONSTRAINT subscribers_user_id_to_user_id_key UNIQUE (user_id, to_user_id);
+
+CREATE INDEX IF NOT EXISTS subscribers_user_id_created_at_id_idx
+    ON subscribers (user_id, created_at, id) INCLUDE (to_user_id, updated_at);
+
+CREATE INDEX IF NOT EXISTS subscribers_to_user_id_created_at_id_idx
+    ON subscribers (to_user_id, created_at, id) INCLUDE (user_id, updated_at);
+
+DROP INDEX IF EXISTS subscribers_to_user_id_user_id_idx;
+CREATE INDEX IF NOT EXISTS subscribers_to_user_id_user_id_idx
+    ON subscribers (to_user_id, user_id) INCLUDE (created_at, updated_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS subscribers_to_user_id_user_id_idx;
+CREATE INDEX IF NOT EXISTS subscribers_to_user_id_user_id_idx
+    ON subscribers (to_user_id, user_id);
+
+DROP INDEX IF EXISTS subscribers_user_id_created_at_id_idx;
+
+ALTER TABLE subscribers DROP CONSTRAINT IF EXISTS subscribers_user_id_to_user_id_key;
+
+ALTER TABLE subscribers DROP COLUMN id;
+ALTER TABLE subscribers ADD COLUMN id SERIAL PRIMARY KEY;
+
+CREATE INDEX IF NOT EXISTS subscribers_to_user_id_created_at_id_idx
+    ON subscribers (to_user_id, created_at, id);
+-- +goose StatementEnd
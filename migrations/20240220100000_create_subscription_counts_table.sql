@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS subscription_counts
+(
+    user_id    UUID PRIMARY KEY,
+    followers  BIGINT                   NOT NULL DEFAULT 0,
+    following  BIGINT                   NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO subscription_counts (user_id, followers, following)
+SELECT user_id, SUM(followers), SUM(following)
+FROM (SELECT to_user_id AS user_id, COUNT(*) AS followers, 0 AS following
+      FROM subscribers
+      GROUP BY to_user_id
+      UNION ALL
+      SELECT user_id, 0, COUNT(*)
+      FROM subscribers
+      GROUP BY user_id) counts
+GROUP BY user_id
+ON CONFLICT (user_id) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS subscription_counts;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE subscribers
+    ADD COLUMN IF NOT EXISTS level TEXT   NOT NULL DEFAULT 'all' CHECK (level IN ('all', 'types', 'none')),
+    ADD COLUMN IF NOT EXISTS types TEXT[] NOT NULL DEFAULT '{}';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE subscribers
+    DROP COLUMN IF EXISTS types,
+    DROP COLUMN IF EXISTS level;
+-- +goose StatementEnd